@@ -0,0 +1,247 @@
+package render
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ErrBundleChecksum is returned by ReadBundle when a bundle's contents
+// don't match its checksum
+var ErrBundleChecksum = errors.New("template bundle checksum mismatch")
+
+// ErrStaleBundle is returned by CheckBundle, and LoadBundle in dev mode,
+// when template files on disk differ from the bundled copies
+var ErrStaleBundle = errors.New("template bundle is stale")
+
+// Bundle holds the sources and set configuration of an application's
+// templates. It is built and validated ahead of time, e.g., with
+// `rendercheck bundle`, and embedded in the binary, so a cold start
+// reads no template files and can't hit a parse error:
+//
+//	//go:embed views.bundle
+//	var views []byte
+//
+//	templates, err := render.LoadBundle(views)
+//
+// Call CheckBundle(views) from a test to catch a bundle that wasn't
+// rebuilt after a template changed.
+type Bundle struct {
+	Base     []string          `json:"base"`
+	Sets     [][]string        `json:"sets"`
+	Files    map[string]string `json:"files"` // contents by path
+	Checksum string            `json:"checksum"`
+}
+
+// NewBundle reads the files of *sets* and *baseTemplates*, as passed to
+// ParseTemplates, and checks that they parse
+func NewBundle(sets [][]string, baseTemplates ...string) (*Bundle, error) {
+
+	b := &Bundle{
+		Base:  baseTemplates,
+		Sets:  sets,
+		Files: make(map[string]string),
+	}
+
+	for _, path := range b.paths() {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		b.Files[path] = string(content)
+	}
+
+	b.Checksum = b.sum()
+
+	if _, err := b.Templates(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// paths returns every file the bundle's sets use, once
+func (b *Bundle) paths() []string {
+
+	var paths []string
+	seen := make(map[string]bool)
+
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	for _, path := range b.Base {
+		add(path)
+	}
+
+	for _, set := range b.Sets {
+		if len(set) > 1 {
+			for _, path := range set[1:] {
+				add(path)
+			}
+		}
+	}
+
+	return paths
+}
+
+// sum hashes the set configuration and file contents
+func (b *Bundle) sum() string {
+
+	h := sha256.New()
+
+	config, _ := json.Marshal(struct {
+		Base []string   `json:"base"`
+		Sets [][]string `json:"sets"`
+	}{b.Base, b.Sets})
+	h.Write(config)
+
+	paths := make([]string, 0, len(b.Files))
+	for path := range b.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		fmt.Fprintf(h, "\x00%s\x00%d\x00", path, len(b.Files[path]))
+		io.WriteString(h, b.Files[path])
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// WriteTo writes the bundle as gzipped JSON
+func (b *Bundle) WriteTo(w io.Writer) (int64, error) {
+
+	var buf bytes.Buffer
+
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(b); err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+
+	return buf.WriteTo(w)
+}
+
+// ReadBundle reads a bundle written by WriteTo and verifies its checksum
+func ReadBundle(r io.Reader) (*Bundle, error) {
+
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var b Bundle
+	if err := json.NewDecoder(zr).Decode(&b); err != nil {
+		return nil, err
+	}
+
+	if b.sum() != b.Checksum {
+		return nil, ErrBundleChecksum
+	}
+
+	return &b, nil
+}
+
+// Changed returns the bundled files whose copies on disk differ. Files
+// missing from disk are not reported, since deployments often ship
+// only the bundle.
+func (b *Bundle) Changed() ([]string, error) {
+
+	var changed []string
+
+	for _, path := range b.paths() {
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if string(content) != b.Files[path] {
+			changed = append(changed, path)
+		}
+	}
+
+	return changed, nil
+}
+
+// Templates parses the bundled sources like ParseTemplates, without
+// touching the disk
+func (b *Bundle) Templates() (map[string]*template.Template, error) {
+
+	read := func(path string) ([]byte, error) {
+		content, ok := b.Files[path]
+		if !ok {
+			return nil, fmt.Errorf("template bundle has no file %s", path)
+		}
+		return []byte(content), nil
+	}
+
+	return parseTemplates(read, b.Sets, b.Base)
+}
+
+// LoadBundle reads a bundle written by WriteTo and parses its templates.
+// It fails with ErrBundleChecksum if the bundle is corrupt. It doesn't
+// read the template files on disk, except in dev mode (see SetDevMode),
+// where it fails with ErrStaleBundle like CheckBundle.
+func LoadBundle(data []byte) (map[string]*template.Template, error) {
+
+	b, err := ReadBundle(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if DevMode() {
+		if err := b.checkStale(); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Templates()
+}
+
+// CheckBundle reads a bundle written by WriteTo and fails with
+// ErrStaleBundle if template files present on disk have changed since
+// it was built, e.g., from a test, so an edited template can't ship
+// with an old bundle
+func CheckBundle(data []byte) error {
+
+	b, err := ReadBundle(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	return b.checkStale()
+}
+
+func (b *Bundle) checkStale() error {
+
+	changed, err := b.Changed()
+	if err != nil {
+		return err
+	}
+
+	if len(changed) > 0 {
+		return fmt.Errorf("%w: %s changed; rebuild it", ErrStaleBundle, strings.Join(changed, ", "))
+	}
+
+	return nil
+}
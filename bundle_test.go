@@ -0,0 +1,67 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadBundle(t *testing.T) {
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "page.html")
+	if err := os.WriteFile(path, []byte(`<p>{{.}}</p>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewBundle([][]string{{"page", path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := b.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	if err := CheckBundle(data); err != nil {
+		t.Errorf("CheckBundle = %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`<p>{{.}}!</p>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CheckBundle(data); !errors.Is(err, ErrStaleBundle) {
+		t.Errorf("CheckBundle after edit = %v, want ErrStaleBundle", err)
+	}
+
+	// Outside dev mode, loading doesn't look at the disk
+	templates, err := LoadBundle(data)
+	if err != nil {
+		t.Fatalf("LoadBundle = %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := templates["page"].Execute(&out, "x"); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "<p>x</p>" {
+		t.Errorf("got %q, want the bundled template", out.String())
+	}
+
+	SetDevMode(true)
+	defer SetDevMode(false)
+
+	if _, err := LoadBundle(data); !errors.Is(err, ErrStaleBundle) {
+		t.Errorf("LoadBundle in dev mode = %v, want ErrStaleBundle", err)
+	}
+
+	data[len(data)/2] ^= 0xff
+	if _, err := LoadBundle(data); err == nil {
+		t.Error("LoadBundle of a corrupt bundle succeeded")
+	}
+}
@@ -0,0 +1,490 @@
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Period names accepted by Calendar.Label, Calendar.Range and the
+// periodLabel/periodStart/periodEnd template functions.
+const (
+	PeriodYear    = "year"
+	PeriodQuarter = "quarter"
+	PeriodTerm    = "term"
+	PeriodWeek    = "week"
+)
+
+// Calendar describes an academic or fiscal year: when it starts,
+// how it is split into terms, and which days are holidays.
+// Build one with LoadCalendar or ParseCalendar; a Calendar
+// assembled in Go must have Init called before use, which
+// SetCalendar does.
+//
+// Example JSON:
+//
+//	{
+//	  "name": "School year",
+//	  "startMonth": 8, "startDay": 15,
+//	  "terms": [
+//	    {"name": "Fall", "start": "08-15", "end": "12-20"},
+//	    {"name": "Spring", "start": "01-06", "end": "06-10"}
+//	  ],
+//	  "holidays": [
+//	    {"name": "Thanksgiving", "date": "2025-11-27", "end": "2025-11-28"}
+//	  ]
+//	}
+type Calendar struct {
+	Name       string `json:"name" yaml:"name"`
+	StartMonth int    `json:"startMonth" yaml:"startMonth"`
+	StartDay   int    `json:"startDay" yaml:"startDay"`
+
+	// Fiscal labels years by the calendar year they end in ("FY2026")
+	// instead of the academic "2025-26".
+	Fiscal bool `json:"fiscal" yaml:"fiscal"`
+
+	// TimeZone is an IANA name; empty means America/New_York, like the other helpers.
+	TimeZone string `json:"timeZone" yaml:"timeZone"`
+
+	Terms    []Term    `json:"terms" yaml:"terms"`
+	Holidays []Holiday `json:"holidays" yaml:"holidays"`
+
+	loc *time.Location
+}
+
+// Term is a named span of the year. Start and End are "MM-DD"
+// and inclusive; a term may cross December 31. "02-29" falls on
+// February 28 outside leap years.
+type Term struct {
+	Name  string `json:"name" yaml:"name"`
+	Start string `json:"start" yaml:"start"`
+	End   string `json:"end" yaml:"end"`
+
+	start, end monthDay
+}
+
+// Holiday is a single day or, when End is set, an inclusive range
+// of days. Dates are "2006-01-02".
+type Holiday struct {
+	Name string `json:"name" yaml:"name"`
+	Date string `json:"date" yaml:"date"`
+	End  string `json:"end,omitempty" yaml:"end,omitempty"`
+
+	from, to time.Time
+}
+
+type monthDay struct {
+	month time.Month
+	day   int
+}
+
+// LoadCalendar reads a calendar definition from a .json, .yaml or .yml file
+func LoadCalendar(path string) (*Calendar, error) {
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseCalendar(b, filepath.Ext(path))
+}
+
+// ParseCalendar decodes a calendar definition. *format* is "json", "yaml"
+// or "yml", with or without a leading dot.
+func ParseCalendar(b []byte, format string) (*Calendar, error) {
+
+	c := &Calendar{}
+
+	switch strings.ToLower(strings.TrimPrefix(format, ".")) {
+	case "json":
+		if err := json.Unmarshal(b, c); err != nil {
+			return nil, err
+		}
+	case "yaml", "yml":
+		if err := yaml.Unmarshal(b, c); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported calendar format %q", format)
+	}
+
+	if err := c.Init(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Init validates the definition and prepares it for use
+func (c *Calendar) Init() error {
+
+	if c.StartMonth < 1 || c.StartMonth > 12 {
+		return fmt.Errorf("calendar %q: startMonth must be 1-12", c.Name)
+	}
+
+	if c.StartDay < 1 || c.StartDay > 28 {
+		return fmt.Errorf("calendar %q: startDay must be 1-28", c.Name)
+	}
+
+	c.loc = location()
+	if c.TimeZone != "" {
+		loc, err := time.LoadLocation(c.TimeZone)
+		if err != nil {
+			return fmt.Errorf("calendar %q: %v", c.Name, err)
+		}
+		c.loc = loc
+	}
+
+	for i := range c.Terms {
+		t := &c.Terms[i]
+
+		var err error
+		if t.start, err = parseMonthDay(t.Start); err != nil {
+			return fmt.Errorf("calendar %q: term %q: %v", c.Name, t.Name, err)
+		}
+		if t.end, err = parseMonthDay(t.End); err != nil {
+			return fmt.Errorf("calendar %q: term %q: %v", c.Name, t.Name, err)
+		}
+	}
+
+	for i := range c.Holidays {
+		h := &c.Holidays[i]
+
+		var err error
+		if h.from, err = time.ParseInLocation("2006-01-02", h.Date, c.loc); err != nil {
+			return fmt.Errorf("calendar %q: holiday %q: %v", c.Name, h.Name, err)
+		}
+
+		h.to = h.from
+		if h.End != "" {
+			if h.to, err = time.ParseInLocation("2006-01-02", h.End, c.loc); err != nil {
+				return fmt.Errorf("calendar %q: holiday %q: %v", c.Name, h.Name, err)
+			}
+			if h.to.Before(h.from) {
+				return fmt.Errorf("calendar %q: holiday %q ends before it starts", c.Name, h.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseMonthDay reads "MM-DD". It allows "02-29", which has no year to
+// be checked against.
+func parseMonthDay(s string) (monthDay, error) {
+
+	m, d, ok := strings.Cut(s, "-")
+	if !ok || len(m) != 2 || len(d) != 2 || strings.Trim(m+d, "0123456789") != "" {
+		return monthDay{}, fmt.Errorf("%q is not MM-DD", s)
+	}
+
+	month, err := strconv.Atoi(m)
+	if err != nil || month < 1 || month > 12 {
+		return monthDay{}, fmt.Errorf("%q is not MM-DD", s)
+	}
+
+	day, err := strconv.Atoi(d)
+	if err != nil || day < 1 || day > daysIn(time.Month(month), 2024) {
+		return monthDay{}, fmt.Errorf("%q is not a day of the year", s)
+	}
+
+	return monthDay{month: time.Month(month), day: day}, nil
+}
+
+// in returns *md* in *year*; February 29 is February 28 outside leap years
+func (md monthDay) in(year int, loc *time.Location) time.Time {
+	day := md.day
+	if n := daysIn(md.month, year); day > n {
+		day = n
+	}
+
+	return time.Date(year, md.month, day, 0, 0, 0, 0, loc)
+}
+
+func daysIn(month time.Month, year int) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// zone returns the calendar's time zone, or the package's when Init
+// has not been called
+func (c *Calendar) zone() *time.Location {
+	if c.loc == nil {
+		return location()
+	}
+
+	return c.loc
+}
+
+// day truncates *t* to midnight in the calendar's zone
+func (c *Calendar) day(t time.Time) time.Time {
+	loc := c.zone()
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// onOrAfter returns the first occurrence of *md* on or after *from*
+func (c *Calendar) onOrAfter(md monthDay, from time.Time) time.Time {
+	d := md.in(from.Year(), c.zone())
+	if d.Before(from) {
+		d = md.in(from.Year()+1, c.zone())
+	}
+
+	return d
+}
+
+// YearRange returns the first and last day of the year containing *t*
+func (c *Calendar) YearRange(t time.Time) (start, end time.Time) {
+	t = c.day(t)
+
+	start = time.Date(t.Year(), time.Month(c.StartMonth), c.StartDay, 0, 0, 0, 0, c.zone())
+	if t.Before(start) {
+		start = start.AddDate(-1, 0, 0)
+	}
+
+	return start, start.AddDate(1, 0, -1)
+}
+
+// YearLabel returns "2025-26" for an academic calendar or "FY2026" for a fiscal one
+func (c *Calendar) YearLabel(t time.Time) string {
+	start, end := c.YearRange(t)
+
+	if c.Fiscal {
+		return fmt.Sprintf("FY%d", end.Year())
+	}
+
+	if start.Year() == end.Year() {
+		return fmt.Sprintf("%d", start.Year())
+	}
+
+	return fmt.Sprintf("%d-%02d", start.Year(), end.Year()%100)
+}
+
+// Quarter returns 1-4, counted from the start of the year
+func (c *Calendar) Quarter(t time.Time) int {
+	start, _ := c.YearRange(t)
+	t = c.day(t)
+
+	q := 1
+	for q < 4 && !t.Before(start.AddDate(0, 3*q, 0)) {
+		q++
+	}
+
+	return q
+}
+
+// QuarterRange returns the first and last day of the quarter containing *t*
+func (c *Calendar) QuarterRange(t time.Time) (start, end time.Time) {
+	yearStart, _ := c.YearRange(t)
+	q := c.Quarter(t)
+
+	start = yearStart.AddDate(0, 3*(q-1), 0)
+	return start, start.AddDate(0, 3, -1)
+}
+
+// QuarterLabel returns, e.g., "Q2 2025-26"
+func (c *Calendar) QuarterLabel(t time.Time) string {
+	return fmt.Sprintf("Q%d %s", c.Quarter(t), c.YearLabel(t))
+}
+
+// Term returns the term containing *t* and its dates, with ok false
+// when *t* falls between terms.
+func (c *Calendar) Term(t time.Time) (term Term, start, end time.Time, ok bool) {
+	yearStart, _ := c.YearRange(t)
+	t = c.day(t)
+
+	for _, tm := range c.Terms {
+		s := c.onOrAfter(tm.start, yearStart)
+		e := c.onOrAfter(tm.end, s)
+
+		if !t.Before(s) && !t.After(e) {
+			return tm, s, e, true
+		}
+	}
+
+	return Term{}, time.Time{}, time.Time{}, false
+}
+
+// TermLabel returns, e.g., "Fall 2025-26", or "" between terms
+func (c *Calendar) TermLabel(t time.Time) string {
+	tm, _, _, ok := c.Term(t)
+	if !ok {
+		return ""
+	}
+
+	return tm.Name + " " + c.YearLabel(t)
+}
+
+// WeekNumber returns the school week of *t*. Weeks start on Monday and
+// week 1 is the week containing the first day of the year.
+func (c *Calendar) WeekNumber(t time.Time) int {
+	start, _ := c.YearRange(t)
+
+	return daysBetween(mondayOf(start), mondayOf(c.day(t)))/7 + 1
+}
+
+// WeekRange returns the Monday and Sunday of the week containing *t*
+func (c *Calendar) WeekRange(t time.Time) (start, end time.Time) {
+	start = mondayOf(c.day(t))
+	return start, start.AddDate(0, 0, 6)
+}
+
+// Holiday returns the holiday falling on *t*, if any
+func (c *Calendar) Holiday(t time.Time) (Holiday, bool) {
+	t = c.day(t)
+
+	for _, h := range c.Holidays {
+		if !t.Before(h.from) && !t.After(h.to) {
+			return h, true
+		}
+	}
+
+	return Holiday{}, false
+}
+
+// IsHoliday reports whether *t* is a holiday
+func (c *Calendar) IsHoliday(t time.Time) bool {
+	_, ok := c.Holiday(t)
+	return ok
+}
+
+// Label names the *period* containing *t*
+func (c *Calendar) Label(period string, t time.Time) (string, error) {
+	switch period {
+	case PeriodYear:
+		return c.YearLabel(t), nil
+	case PeriodQuarter:
+		return c.QuarterLabel(t), nil
+	case PeriodTerm:
+		return c.TermLabel(t), nil
+	case PeriodWeek:
+		return fmt.Sprintf("Week %d", c.WeekNumber(t)), nil
+	}
+
+	return "", fmt.Errorf("unknown calendar period %q", period)
+}
+
+// Range returns the first and last day of the *period* containing *t*.
+// Between terms, the term range is two zero times.
+func (c *Calendar) Range(period string, t time.Time) (start, end time.Time, err error) {
+	switch period {
+	case PeriodYear:
+		start, end = c.YearRange(t)
+	case PeriodQuarter:
+		start, end = c.QuarterRange(t)
+	case PeriodTerm:
+		_, start, end, _ = c.Term(t)
+	case PeriodWeek:
+		start, end = c.WeekRange(t)
+	default:
+		err = fmt.Errorf("unknown calendar period %q", period)
+	}
+
+	return start, end, err
+}
+
+func mondayOf(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -offset)
+}
+
+// daysBetween counts whole days from *a* to *b*, both at midnight,
+// rounding so DST transitions don't lose a day.
+func daysBetween(a, b time.Time) int {
+	return int((b.Sub(a) + 12*time.Hour) / (24 * time.Hour))
+}
+
+var (
+	defaultCalendarMu sync.RWMutex
+	defaultCalendar   *Calendar
+)
+
+// ErrNoCalendar is returned by the calendar template functions
+// when SetCalendar has not been called.
+var ErrNoCalendar = errors.New("no calendar configured; call render.SetCalendar")
+
+// SetCalendar validates *c* with Init and sets it as the calendar used
+// by the template functions. Passing nil removes it.
+func SetCalendar(c *Calendar) error {
+
+	if c != nil {
+		if err := c.Init(); err != nil {
+			return err
+		}
+	}
+
+	defaultCalendarMu.Lock()
+	defaultCalendar = c
+	defaultCalendarMu.Unlock()
+
+	return nil
+}
+
+// DefaultCalendar returns the calendar set by SetCalendar, or nil
+func DefaultCalendar() *Calendar {
+	defaultCalendarMu.RLock()
+	defer defaultCalendarMu.RUnlock()
+
+	return defaultCalendar
+}
+
+// PeriodLabel labels *t* with its *period* on the default calendar,
+// e.g., {{periodLabel "term" .Date}} -> "Fall 2025-26"
+func PeriodLabel(period string, t time.Time) (string, error) {
+	c := DefaultCalendar()
+	if c == nil {
+		return "", ErrNoCalendar
+	}
+
+	return c.Label(period, t)
+}
+
+// PeriodStart returns the first day of the *period* containing *t* on the default calendar
+func PeriodStart(period string, t time.Time) (time.Time, error) {
+	c := DefaultCalendar()
+	if c == nil {
+		return time.Time{}, ErrNoCalendar
+	}
+
+	start, _, err := c.Range(period, t)
+	return start, err
+}
+
+// PeriodEnd returns the last day of the *period* containing *t* on the default calendar
+func PeriodEnd(period string, t time.Time) (time.Time, error) {
+	c := DefaultCalendar()
+	if c == nil {
+		return time.Time{}, ErrNoCalendar
+	}
+
+	_, end, err := c.Range(period, t)
+	return end, err
+}
+
+// SchoolWeek returns the week number of *t* on the default calendar
+func SchoolWeek(t time.Time) (int, error) {
+	c := DefaultCalendar()
+	if c == nil {
+		return 0, ErrNoCalendar
+	}
+
+	return c.WeekNumber(t), nil
+}
+
+// HolidayName returns the name of the holiday on *t*, or ""
+func HolidayName(t time.Time) (string, error) {
+	c := DefaultCalendar()
+	if c == nil {
+		return "", ErrNoCalendar
+	}
+
+	h, _ := c.Holiday(t)
+	return h.Name, nil
+}
@@ -0,0 +1,219 @@
+package render
+
+import (
+	"testing"
+	"time"
+)
+
+const schoolYear = `{
+  "name": "School year",
+  "startMonth": 8, "startDay": 15,
+  "timeZone": "America/New_York",
+  "terms": [
+    {"name": "Fall", "start": "08-15", "end": "12-20"},
+    {"name": "Winter", "start": "12-21", "end": "01-05"},
+    {"name": "Spring", "start": "01-06", "end": "06-10"}
+  ],
+  "holidays": [
+    {"name": "Leap Day", "date": "2024-02-29"},
+    {"name": "Thanksgiving", "date": "2025-11-27", "end": "2025-11-28"}
+  ]
+}`
+
+func newCalendar(t *testing.T, def string) *Calendar {
+	t.Helper()
+
+	c, err := ParseCalendar([]byte(def), "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 12, 0, 0, 0, time.UTC)
+}
+
+func TestParseMonthDay(t *testing.T) {
+
+	tests := []struct {
+		in   string
+		want monthDay
+		ok   bool
+	}{
+		{"08-15", monthDay{time.August, 15}, true},
+		{"12-31", monthDay{time.December, 31}, true},
+		{"02-29", monthDay{time.February, 29}, true},
+		{"02-30", monthDay{}, false},
+		{"04-31", monthDay{}, false},
+		{"13-01", monthDay{}, false},
+		{"00-10", monthDay{}, false},
+		{"8-15", monthDay{}, false},
+		{"08/15", monthDay{}, false},
+		{"+8-15", monthDay{}, false},
+		{"", monthDay{}, false},
+	}
+
+	for _, tt := range tests {
+		got, err := parseMonthDay(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("%q: err = %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCalendarTerm(t *testing.T) {
+
+	c := newCalendar(t, schoolYear)
+
+	tests := []struct {
+		name       string
+		t          time.Time
+		term       string
+		start, end string
+		label      string
+	}{
+		{"first day", day(2025, 8, 15), "Fall", "2025-08-15", "2025-12-20", "Fall 2025-26"},
+		{"summer before", day(2025, 8, 14), "", "", "", ""},
+		{"across Dec 31", day(2025, 12, 31), "Winter", "2025-12-21", "2026-01-05", "Winter 2025-26"},
+		{"after Jan 1", day(2026, 1, 5), "Winter", "2025-12-21", "2026-01-05", "Winter 2025-26"},
+		{"next term", day(2026, 1, 6), "Spring", "2026-01-06", "2026-06-10", "Spring 2025-26"},
+		{"summer", day(2026, 7, 1), "", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm, start, end, ok := c.Term(tt.t)
+			if ok != (tt.term != "") || tm.Name != tt.term {
+				t.Fatalf("got %q, ok %v, want %q", tm.Name, ok, tt.term)
+			}
+			if ok && (start.Format(time.DateOnly) != tt.start || end.Format(time.DateOnly) != tt.end) {
+				t.Errorf("range %s to %s, want %s to %s", start.Format(time.DateOnly), end.Format(time.DateOnly), tt.start, tt.end)
+			}
+			if got := c.TermLabel(tt.t); got != tt.label {
+				t.Errorf("TermLabel = %q, want %q", got, tt.label)
+			}
+		})
+	}
+}
+
+// A term ending on Feb 29 ends on Feb 28 outside leap years
+func TestCalendarLeapDayTerm(t *testing.T) {
+
+	c := newCalendar(t, `{"startMonth": 9, "startDay": 1, "terms": [{"name": "Winter", "start": "12-01", "end": "02-29"}]}`)
+
+	for _, tt := range []struct {
+		t   time.Time
+		end string
+		ok  bool
+	}{
+		{day(2024, 2, 29), "2024-02-29", true},
+		{day(2024, 3, 1), "", false},
+		{day(2025, 2, 28), "2025-02-28", true},
+		{day(2025, 3, 1), "", false},
+	} {
+		_, _, end, ok := c.Term(tt.t)
+		if ok != tt.ok || (ok && end.Format(time.DateOnly) != tt.end) {
+			t.Errorf("%s: end %s, ok %v", tt.t.Format(time.DateOnly), end.Format(time.DateOnly), ok)
+		}
+	}
+}
+
+func TestCalendarWeekNumber(t *testing.T) {
+
+	// The year starts on Friday 2025-08-15, so week 1 began Monday 2025-08-11
+	c := newCalendar(t, schoolYear)
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want int
+	}{
+		{"first day", day(2025, 8, 15), 1},
+		{"first Sunday", day(2025, 8, 17), 1},
+		{"first Monday", day(2025, 8, 18), 2},
+		{"after DST ends", day(2025, 11, 3), 13},
+		{"last day of Fall", day(2025, 12, 20), 19},
+		{"first day of Winter", day(2025, 12, 21), 19},
+		{"last day of Winter", day(2026, 1, 5), 22},
+		{"first day of Spring", day(2026, 1, 6), 22},
+		{"last day of the year", day(2026, 8, 14), 53},
+		{"next year", day(2026, 8, 15), 1},
+	}
+
+	for _, tt := range tests {
+		if got := c.WeekNumber(tt.t); got != tt.want {
+			t.Errorf("%s: week %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCalendarLabels(t *testing.T) {
+
+	fiscal := newCalendar(t, `{"name": "FY", "startMonth": 7, "startDay": 1, "fiscal": true}`)
+	federal := newCalendar(t, `{"name": "Federal", "startMonth": 10, "startDay": 1, "fiscal": true}`)
+	school := newCalendar(t, schoolYear)
+	plain := newCalendar(t, `{"startMonth": 1, "startDay": 1}`)
+
+	tests := []struct {
+		name    string
+		c       *Calendar
+		t       time.Time
+		year    string
+		quarter string
+	}{
+		{"fiscal last day", fiscal, day(2025, 6, 30), "FY2025", "Q4 FY2025"},
+		{"fiscal first day", fiscal, day(2025, 7, 1), "FY2026", "Q1 FY2026"},
+		{"fiscal Q2", fiscal, day(2025, 10, 1), "FY2026", "Q2 FY2026"},
+		{"fiscal Q3 in the next year", fiscal, day(2026, 1, 1), "FY2026", "Q3 FY2026"},
+		{"federal", federal, day(2025, 10, 1), "FY2026", "Q1 FY2026"},
+		{"federal before", federal, day(2025, 9, 30), "FY2025", "Q4 FY2025"},
+		{"school", school, day(2026, 3, 1), "2025-26", "Q3 2025-26"},
+		{"school before start", school, day(2025, 8, 14), "2024-25", "Q4 2024-25"},
+		{"calendar year", plain, day(2025, 12, 31), "2025", "Q4 2025"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.c.YearLabel(tt.t); got != tt.year {
+			t.Errorf("%s: YearLabel = %q, want %q", tt.name, got, tt.year)
+		}
+		if got := tt.c.QuarterLabel(tt.t); got != tt.quarter {
+			t.Errorf("%s: QuarterLabel = %q, want %q", tt.name, got, tt.quarter)
+		}
+	}
+}
+
+func TestCalendarHoliday(t *testing.T) {
+
+	c := newCalendar(t, schoolYear)
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want string
+	}{
+		{"single day", day(2024, 2, 29), "Leap Day"},
+		{"range start", day(2025, 11, 27), "Thanksgiving"},
+		{"range end", day(2025, 11, 28), "Thanksgiving"},
+		{"after range", day(2025, 11, 29), ""},
+		{"before range", day(2025, 11, 26), ""},
+		// 03:00 UTC on the 27th is still the 26th in New York
+		{"time zone", time.Date(2025, 11, 27, 3, 0, 0, 0, time.UTC), ""},
+		{"late in the day", time.Date(2025, 11, 29, 3, 0, 0, 0, time.UTC), "Thanksgiving"},
+	}
+
+	for _, tt := range tests {
+		h, ok := c.Holiday(tt.t)
+		if ok != (tt.want != "") || h.Name != tt.want {
+			t.Errorf("%s: got %q, ok %v, want %q", tt.name, h.Name, ok, tt.want)
+		}
+		if c.IsHoliday(tt.t) != ok {
+			t.Errorf("%s: IsHoliday disagrees with Holiday", tt.name)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SetConfig describes template sets the way ParseTemplates takes them,
+// for tools that can't import the application's Go code:
+//
+//	{
+//	  "base": ["views/master.html"],
+//	  "sets": [["authenticators", "views/authenticators.html"]]
+//	}
+type SetConfig struct {
+	Base []string   `json:"base" yaml:"base"`
+	Sets [][]string `json:"sets" yaml:"sets"`
+}
+
+// LoadSetConfig reads a SetConfig from a .json, .yaml or .yml file
+func LoadSetConfig(path string) (*SetConfig, error) {
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config SetConfig
+
+	switch filepath.Ext(path) {
+	case ".json":
+		err = json.Unmarshal(b, &config)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &config)
+	default:
+		return nil, fmt.Errorf("%s: set config must be .json, .yaml or .yml", path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return &config, nil
+}
+
+// Graph is the {{template}} and {{block}} reference graph of a set configuration
+type Graph struct {
+	Sets  []Set
+	Files map[string]*File // by path
+}
+
+// Set is one template set: its lookup name and files, base templates first
+type Set struct {
+	Name  string
+	Files []string
+}
+
+// File is what one template file defines and references
+type File struct {
+	Path    string
+	Defines []Define
+	Refs    []Ref
+	Funcs   map[string]bool // FuncMap and builtin functions called
+}
+
+// Define is a template a file defines: the file itself, named by its
+// base name, and each {{define}} and {{block}}
+type Define struct {
+	Name  string
+	Pos   Position
+	Block bool // a {{block}} default, meant to be overridden
+	Empty bool // only whitespace and comments, which html/template never lets replace a definition
+}
+
+// Ref is a {{template}} or {{block}} call
+type Ref struct {
+	From    string // the template the call is in
+	Name    string
+	Pos     Position
+	Context string
+}
+
+// BuildGraph parses the files of *sets* the way ParseTemplates would,
+// with *baseTemplates* first in every set
+func BuildGraph(sets [][]string, baseTemplates ...string) (*Graph, error) {
+
+	g := &Graph{Files: make(map[string]*File)}
+
+	for _, s := range sets {
+		if len(s) == 0 {
+			continue
+		}
+
+		set := Set{Name: s[0]}
+		listed := make(map[string]bool)
+
+		for _, path := range append(append([]string(nil), baseTemplates...), s[1:]...) {
+			path = filepath.Clean(path)
+			if listed[path] {
+				continue
+			}
+			listed[path] = true
+			set.Files = append(set.Files, path)
+
+			if _, ok := g.Files[path]; ok {
+				continue
+			}
+
+			f, err := parseGraphFile(path)
+			if err != nil {
+				return nil, err
+			}
+			g.Files[path] = f
+		}
+
+		g.Sets = append(g.Sets, set)
+	}
+
+	return g, nil
+}
+
+func parseGraphFile(path string) (*File, error) {
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	trees, err := ParseText(path, string(b))
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := blockNames(string(b), trees)
+
+	f := &File{Path: path, Funcs: make(map[string]bool)}
+
+	names := make([]string, 0, len(trees))
+	for name := range trees {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		tree := trees[name]
+
+		define := Define{
+			Name:  name,
+			Block: blocks[name],
+			Empty: tree.Root == nil || parse.IsEmptyTree(tree.Root),
+		}
+
+		// The file's own tree is named by its path; html/template names it by base name
+		if name == path {
+			define.Name = filepath.Base(path)
+			define.Pos = Position{File: path, Line: 1, Col: 1}
+		} else if tree.Root != nil {
+			define.Pos = position(tree, tree.Root)
+		}
+
+		f.Defines = append(f.Defines, define)
+
+		if tree.Root == nil {
+			continue
+		}
+
+		walk(tree.Root, func(n parse.Node) {
+			switch n := n.(type) {
+			case *parse.TemplateNode:
+				_, context := tree.ErrorContext(n)
+				f.Refs = append(f.Refs, Ref{
+					From:    define.Name,
+					Name:    n.Name,
+					Pos:     position(tree, n),
+					Context: strings.TrimSuffix(strings.TrimPrefix(context, "{{"), "}}"),
+				})
+			case *parse.IdentifierNode:
+				f.Funcs[n.Ident] = true
+			}
+		})
+	}
+
+	return f, nil
+}
+
+// blockNames returns the templates of *trees* that are {{block}} defaults.
+// The parser turns {{block "x" .}} into a define of x plus a call to it,
+// so a block is a {{template}} call whose definition, in the same file,
+// starts right after the call's action.
+func blockNames(text string, trees map[string]*parse.Tree) map[string]bool {
+
+	blocks := make(map[string]bool)
+
+	for _, tree := range trees {
+		if tree.Root == nil {
+			continue
+		}
+
+		walk(tree.Root, func(n parse.Node) {
+			call, ok := n.(*parse.TemplateNode)
+			if !ok {
+				return
+			}
+
+			def, ok := trees[call.Name]
+			if !ok || def.Root == nil || def.Root.Pos < call.Pos || int(def.Root.Pos) > len(text) {
+				return
+			}
+
+			if !strings.Contains(text[call.Pos:def.Root.Pos], "{{") {
+				blocks[call.Name] = true
+			}
+		})
+	}
+
+	return blocks
+}
+
+// defines returns the templates set *s* ends up with, by name, in the
+// order html/template applies them: a later non-empty definition wins
+func (g *Graph) defines(s Set) map[string][]fileDefine {
+
+	defined := make(map[string][]fileDefine)
+
+	for _, path := range s.Files {
+		for _, d := range g.Files[path].Defines {
+			defined[d.Name] = append(defined[d.Name], fileDefine{Define: d, File: path})
+		}
+	}
+
+	return defined
+}
+
+type fileDefine struct {
+	Define
+	File string
+}
+
+// Undefined reports {{template}} calls naming a template their set doesn't define
+func (g *Graph) Undefined() []Finding {
+
+	var findings []Finding
+
+	for _, s := range g.Sets {
+		defined := g.defines(s)
+
+		for _, path := range s.Files {
+			for _, ref := range g.Files[path].Refs {
+				if _, ok := defined[ref.Name]; ok {
+					continue
+				}
+				findings = append(findings, Finding{
+					Pos:      ref.Pos,
+					Template: ref.From,
+					Func:     ref.Name,
+					Message:  fmt.Sprintf("is not defined in set %s", s.Name),
+					Context:  ref.Context,
+				})
+			}
+		}
+	}
+
+	sortFindings(findings)
+
+	return findings
+}
+
+// Duplicates reports templates defined in more than one file of a set.
+// html/template silently keeps the last definition parsed, so only a
+// {{block}} default being overridden is expected.
+func (g *Graph) Duplicates() []Finding {
+
+	var findings []Finding
+	seen := make(map[string]bool)
+
+	for _, s := range g.Sets {
+		for name, defs := range g.defines(s) {
+			var previous *fileDefine
+
+			for i := range defs {
+				d := &defs[i]
+				if d.Empty {
+					continue
+				}
+
+				if previous != nil && previous.File != d.File && !(previous.Block && !d.Block) {
+					key := d.Pos.String() + previous.File
+					if !seen[key] {
+						seen[key] = true
+						findings = append(findings, Finding{
+							Pos:      d.Pos,
+							Template: name,
+							Func:     name,
+							Message:  fmt.Sprintf("is also defined in %s; set %s uses the definition parsed last", previous.File, s.Name),
+							Context:  fmt.Sprintf("define %q", name),
+						})
+					}
+				}
+
+				previous = d
+			}
+		}
+	}
+
+	sortFindings(findings)
+
+	return findings
+}
+
+// UnusedDefines reports {{define}}s that no set calls
+func (g *Graph) UnusedDefines() []Finding {
+
+	called := make(map[string]bool)
+	for _, f := range g.Files {
+		for _, ref := range f.Refs {
+			called[ref.Name] = true
+		}
+	}
+
+	var findings []Finding
+
+	for path, f := range g.Files {
+		for _, d := range f.Defines {
+			if d.Name == filepath.Base(path) || called[d.Name] {
+				continue
+			}
+			findings = append(findings, Finding{
+				Pos:      d.Pos,
+				Template: d.Name,
+				Func:     d.Name,
+				Message:  "is never called with {{template}}",
+				Context:  fmt.Sprintf("define %q", d.Name),
+			})
+		}
+	}
+
+	sortFindings(findings)
+
+	return findings
+}
+
+// UnusedFiles returns the .html files under *root* that no set includes
+func (g *Graph) UnusedFiles(root string) ([]string, error) {
+
+	files, err := FindTemplates(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var unused []string
+	for _, path := range files {
+		if _, ok := g.Files[filepath.Clean(path)]; !ok {
+			unused = append(unused, path)
+		}
+	}
+
+	return unused, nil
+}
+
+// UnusedFuncs returns the names in *funcs*, e.g., render.GetFuncMap(),
+// that no file of the graph calls, sorted
+func (g *Graph) UnusedFuncs(funcs map[string]interface{}) []string {
+
+	var unused []string
+
+	for name := range funcs {
+		used := false
+		for _, f := range g.Files {
+			if f.Funcs[name] {
+				used = true
+				break
+			}
+		}
+		if !used {
+			unused = append(unused, name)
+		}
+	}
+
+	sort.Strings(unused)
+
+	return unused
+}
+
+// WriteDot writes the graph in Graphviz format: each set points to its
+// files, and each template to the templates it calls
+func (g *Graph) WriteDot(w io.Writer) error {
+
+	var sb strings.Builder
+
+	sb.WriteString("digraph templates {\n\trankdir=LR;\n")
+
+	for _, s := range g.Sets {
+		fmt.Fprintf(&sb, "\t%q [shape=box];\n", "set "+s.Name)
+		for _, path := range s.Files {
+			fmt.Fprintf(&sb, "\t%q -> %q;\n", "set "+s.Name, filepath.Base(path))
+		}
+	}
+
+	paths := make([]string, 0, len(g.Files))
+	for path := range g.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	edges := make(map[string]bool)
+
+	for _, path := range paths {
+		for _, ref := range g.Files[path].Refs {
+			edge := fmt.Sprintf("\t%q -> %q;\n", ref.From, ref.Name)
+			if !edges[edge] {
+				edges[edge] = true
+				sb.WriteString(edge)
+			}
+		}
+	}
+
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,84 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTemplates(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestGraphBlocks(t *testing.T) {
+
+	dir := writeTemplates(t, map[string]string{
+		"master.html": `<title>{{block "title" .}}Shop{{end}}</title>` +
+			`{{- block "nav" . -}}<nav></nav>{{- end -}}` +
+			`{{block "empty" .}}{{end}}` +
+			`{{/* {{block "commented" .}} */}}{{define "commented"}}c{{end}}` +
+			`{{print "{{block \"quoted\" .}}"}}{{define "quoted"}}q{{end}}` +
+			`{{template "called" .}}{{define "called"}}d{{end}}` +
+			`{{define "later"}}l{{end}}{{template "later" .}}`,
+	})
+
+	g, err := BuildGraph([][]string{{"home"}}, filepath.Join(dir, "master.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{
+		"master.html": false,
+		"title":       true,
+		"nav":         true,
+		"empty":       true,
+		"commented":   false,
+		"quoted":      false,
+		"called":      false,
+		"later":       false,
+	}
+
+	f := g.Files[filepath.Join(dir, "master.html")]
+	if len(f.Defines) != len(want) {
+		t.Errorf("got %d defines, want %d", len(f.Defines), len(want))
+	}
+
+	for _, d := range f.Defines {
+		block, ok := want[d.Name]
+		if !ok {
+			t.Errorf("unexpected define %q", d.Name)
+			continue
+		}
+		if d.Block != block {
+			t.Errorf("%s: Block = %v, want %v", d.Name, d.Block, block)
+		}
+	}
+}
+
+// Overriding a {{block}} is expected; overriding a {{define}} is not
+func TestGraphDuplicates(t *testing.T) {
+
+	dir := writeTemplates(t, map[string]string{
+		"master.html": `{{block "title" .}}Shop{{end}}{{template "footer" .}}{{define "footer"}}f{{end}}`,
+		"orders.html": `{{define "title"}}Orders{{end}}{{define "footer"}}g{{end}}`,
+	})
+
+	g, err := BuildGraph([][]string{{"orders", filepath.Join(dir, "orders.html")}}, filepath.Join(dir, "master.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	findings := g.Duplicates()
+	if len(findings) != 1 || findings[0].Func != "footer" {
+		t.Fatalf("got %v, want footer only", findings)
+	}
+}
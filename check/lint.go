@@ -0,0 +1,194 @@
+package check
+
+import (
+	"fmt"
+	"sort"
+	"text/template/parse"
+)
+
+// Sinks lists the FuncMap functions whose output html/template trusts
+// without escaping, with the reason each is reported. Lint flags every
+// call to one of them whose argument comes from model data rather than
+// a literal. Add entries for application-specific helpers.
+var Sinks = map[string]string{
+	"unsafeRawHTML":  "returns its argument as template.HTML without any checks",
+	"safe":           "marks its argument as HTML; it is sanitized, but review that markup is expected here",
+	"htmlEscape":     "marks its argument as HTML; it is sanitized, but review that markup is expected here",
+	"renderFragment": "executes its argument as a template; model data here is template injection",
+	"newLineToBR":    "returns template.HTML; make sure the argument is plain text, not markup",
+	"marshal":        "returns template.JS; prefer jsonScript or jsonScriptFor for model data",
+	"arrayToQS":      "returns template.URL; deprecated, build links with queryString or urlWith",
+}
+
+// Finding is one problem reported by Lint or TypeCheck
+type Finding struct {
+	Pos      Position
+	Template string // the {{define}} or file the call is in
+	Func     string
+	Message  string
+	Context  string // the pipeline as written, e.g., .Notes | safe
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s %s: {{%s}}", f.Pos, f.Func, f.Message, f.Context)
+}
+
+// LintDir lints every .html file under *root*
+func LintDir(root string) ([]Finding, error) {
+
+	files, err := FindTemplates(root)
+	if err != nil {
+		return nil, err
+	}
+
+	return LintFiles(files...)
+}
+
+// LintFiles lints each file on its own and returns the findings sorted by position
+func LintFiles(files ...string) ([]Finding, error) {
+
+	var findings []Finding
+
+	for _, file := range files {
+		trees, err := ParseFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		findings = append(findings, LintTrees(trees)...)
+	}
+
+	sortFindings(findings)
+
+	return findings, nil
+}
+
+// sortFindings orders *findings* by position
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i].Pos, findings[j].Pos
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Col < b.Col
+	})
+}
+
+// LintTrees reports sink calls fed with model data in already-parsed trees
+func LintTrees(trees map[string]*parse.Tree) []Finding {
+
+	var findings []Finding
+
+	for name, tree := range trees {
+		if tree.Root == nil {
+			continue
+		}
+
+		walk(tree.Root, func(n parse.Node) {
+			pipe, ok := n.(*parse.PipeNode)
+			if !ok {
+				return
+			}
+
+			for _, f := range lintPipe(pipe) {
+				f.Template = name
+				f.Pos = position(tree, f.node)
+				_, f.Context = tree.ErrorContext(pipe)
+				findings = append(findings, f.Finding)
+			}
+		})
+	}
+
+	return findings
+}
+
+type nodeFinding struct {
+	Finding
+	node parse.Node
+}
+
+// lintPipe checks each command of *pipe*. In {{.X | safe}} the piped
+// value becomes the last argument of safe, so a command is only fed
+// literals when its own arguments and everything before it are literal.
+func lintPipe(pipe *parse.PipeNode) []nodeFinding {
+
+	var findings []nodeFinding
+
+	pipedLiteral := true
+
+	for i, cmd := range pipe.Cmds {
+		fn, args := calledFunc(cmd)
+
+		literal := allLiteral(args)
+		if i > 0 {
+			literal = literal && pipedLiteral
+		}
+
+		if message, ok := Sinks[fn]; ok && !literal {
+			findings = append(findings, nodeFinding{
+				Finding: Finding{Func: fn, Message: message},
+				node:    cmd,
+			})
+		}
+
+		if fn != "" {
+			pipedLiteral = literal
+		} else {
+			pipedLiteral = allLiteral(cmd.Args)
+		}
+	}
+
+	return findings
+}
+
+// calledFunc returns the function a command calls and its explicit arguments,
+// or "" when the command is an operand such as .Field or "text".
+func calledFunc(cmd *parse.CommandNode) (string, []parse.Node) {
+
+	if len(cmd.Args) == 0 {
+		return "", nil
+	}
+
+	if id, ok := cmd.Args[0].(*parse.IdentifierNode); ok {
+		return id.Ident, cmd.Args[1:]
+	}
+
+	return "", cmd.Args
+}
+
+func allLiteral(nodes []parse.Node) bool {
+
+	for _, n := range nodes {
+		if !isLiteral(n) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isLiteral reports whether *n* is a constant written in the template,
+// or a parenthesized call whose arguments are all constants.
+// Fields and variables are treated as model data.
+func isLiteral(n parse.Node) bool {
+
+	switch n := n.(type) {
+	case *parse.StringNode, *parse.NumberNode, *parse.BoolNode, *parse.NilNode:
+		return true
+	case *parse.PipeNode:
+		if len(n.Decl) > 0 {
+			return false
+		}
+		for _, cmd := range n.Cmds {
+			if _, args := calledFunc(cmd); !allLiteral(args) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
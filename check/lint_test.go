@@ -0,0 +1,77 @@
+package check
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintTrees(t *testing.T) {
+
+	tests := []struct {
+		name string
+		text string
+		want []string // sinks reported, in order
+	}{
+		// Model data reaching a sink
+		{"field piped", `{{.Notes | safe}}`, []string{"safe"}},
+		{"field argument", `{{unsafeRawHTML .Notes}}`, []string{"unsafeRawHTML"}},
+		{"dot", `{{with .Notes}}{{unsafeRawHTML .}}{{end}}`, []string{"unsafeRawHTML"}},
+		{"variable", `{{$body := .Body}}{{unsafeRawHTML $body}}`, []string{"unsafeRawHTML"}},
+		{"variable holding a literal", `{{$b := "<b>"}}{{safe $b}}`, []string{"safe"}},
+		{"printf of a field", `{{unsafeRawHTML (printf "<b>%s</b>" .Name)}}`, []string{"unsafeRawHTML"}},
+		{"field through printf", `{{.Name | printf "<b>%s</b>" | unsafeRawHTML}}`, []string{"unsafeRawHTML"}},
+		{"literal format, field piped", `{{printf "<b>%s</b>" .Name | safe}}`, []string{"safe"}},
+		{"two sinks", `{{safe .A}}<p>{{renderFragment .B .}}</p>`, []string{"safe", "renderFragment"}},
+		{"in a define", `{{define "row"}}{{newLineToBR .Notes}}{{end}}`, []string{"newLineToBR"}},
+
+		// Literals only
+		{"string literal", `{{unsafeRawHTML "<b>hi</b>"}}`, nil},
+		{"piped literal", `{{"<b>hi</b>" | safe}}`, nil},
+		{"printf of literals", `{{unsafeRawHTML (printf "<b>%d</b>" 3)}}`, nil},
+		{"printf of literals piped", `{{printf "<i>%s</i>" "x" | unsafeRawHTML}}`, nil},
+		{"not a sink", `{{printf "%s" .Notes}}`, nil},
+		{"sink output used as data", `{{printf "%s" (safe "<b>")}}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trees, err := ParseText("page.html", tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			findings := LintTrees(trees)
+			sortFindings(findings)
+
+			var got []string
+			for _, f := range findings {
+				got = append(got, f.Func)
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintTreesFinding(t *testing.T) {
+
+	trees, err := ParseText("page.html", "<p>\n  {{.Notes | safe}}</p>")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	findings := LintTrees(trees)
+	if len(findings) != 1 {
+		t.Fatalf("got %v", findings)
+	}
+
+	f := findings[0]
+	if f.Pos.File != "page.html" || f.Pos.Line != 2 || f.Template != "page.html" || f.Context != ".Notes | safe" {
+		t.Errorf("got %+v", f)
+	}
+	if f.Message != Sinks["safe"] {
+		t.Errorf("Message = %q", f.Message)
+	}
+}
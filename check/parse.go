@@ -0,0 +1,163 @@
+// Package check statically analyzes the HTML templates rendered with
+// github.com/bjbigler/render, using the same FuncMap as production.
+package check
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+
+	"github.com/bjbigler/render"
+)
+
+// builtins are the functions text/template predefines. The parser only
+// checks that a name maps to a non-nil value, so any function will do.
+var builtins = func() map[string]any {
+	m := make(map[string]any)
+
+	for _, name := range []string{
+		"and", "call", "html", "index", "slice", "js", "len", "not", "or",
+		"print", "printf", "println", "urlquery",
+		"eq", "ge", "gt", "le", "lt", "ne",
+	} {
+		m[name] = fmt.Sprint
+	}
+
+	return m
+}()
+
+// ParseFile parses one template file with the package FuncMap and returns
+// its trees: the file itself, keyed by its path, and each {{define}} and
+// {{block}} it contains.
+func ParseFile(path string) (map[string]*parse.Tree, error) {
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseText(path, string(b))
+}
+
+// ParseText parses template *text* as if read from file *name*
+func ParseText(name, text string) (map[string]*parse.Tree, error) {
+
+	trees := make(map[string]*parse.Tree)
+
+	t := parse.New(name)
+	t.Mode = parse.ParseComments
+
+	if _, err := t.Parse(text, "", "", trees, render.GetFuncMap(), builtins); err != nil {
+		return nil, err
+	}
+
+	return trees, nil
+}
+
+// FindTemplates returns the .html files under *root*, sorted
+func FindTemplates(root string) ([]string, error) {
+
+	var files []string
+
+	err := filepath.Walk(filepath.Clean(root), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() && strings.HasSuffix(path, ".html") {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	sort.Strings(files)
+
+	return files, err
+}
+
+// Position is a location in a template file
+type Position struct {
+	File string
+	Line int
+	Col  int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Col)
+}
+
+// position resolves *n* to file:line:col using the tree it was parsed in
+func position(tree *parse.Tree, n parse.Node) Position {
+
+	location, _ := tree.ErrorContext(n)
+
+	// location is "file:line:col"; the file name may itself contain colons
+	parts := strings.Split(location, ":")
+	if len(parts) < 3 {
+		return Position{File: location}
+	}
+
+	line, _ := strconv.Atoi(parts[len(parts)-2])
+	col, _ := strconv.Atoi(parts[len(parts)-1])
+
+	return Position{
+		File: strings.Join(parts[:len(parts)-2], ":"),
+		Line: line,
+		Col:  col,
+	}
+}
+
+// walk calls *fn* for *n* and every node below it
+func walk(n parse.Node, fn func(parse.Node)) {
+
+	if n == nil {
+		return
+	}
+
+	fn(n)
+
+	switch n := n.(type) {
+	case *parse.ListNode:
+		for _, c := range n.Nodes {
+			walk(c, fn)
+		}
+	case *parse.ActionNode:
+		walk(n.Pipe, fn)
+	case *parse.PipeNode:
+		for _, d := range n.Decl {
+			walk(d, fn)
+		}
+		for _, c := range n.Cmds {
+			walk(c, fn)
+		}
+	case *parse.CommandNode:
+		for _, a := range n.Args {
+			walk(a, fn)
+		}
+	case *parse.ChainNode:
+		walk(n.Node, fn)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.TemplateNode:
+		if n.Pipe != nil {
+			walk(n.Pipe, fn)
+		}
+	}
+}
+
+func walkBranch(b *parse.BranchNode, fn func(parse.Node)) {
+	walk(b.Pipe, fn)
+	walk(b.List, fn)
+	if b.ElseList != nil {
+		walk(b.ElseList, fn)
+	}
+}
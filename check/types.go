@@ -0,0 +1,688 @@
+package check
+
+import (
+	"fmt"
+	"html/template"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	"github.com/bjbigler/render"
+)
+
+// Models declares the model each template set is rendered with, by set
+// name and a value of the model type:
+//
+//	check.Models{
+//		"dashboard": DashboardModel{},
+//		"student":   (*StudentModel)(nil),
+//	}
+type Models map[string]interface{}
+
+// TypeCheck walks each set in *templates* that has a declared model and
+// reports field chains the model type doesn't have, e.g., {{.Usr.Name}},
+// and FuncMap calls with the wrong number or types of arguments.
+// Templates reached with {{template}} are checked with the type of the
+// value passed to them. Values typed interface{} can't be checked, so
+// anything below them is skipped.
+//
+// Run it from a test, or from a small command that imports the models:
+//
+//	findings, err := check.TypeCheck(templates, check.Models{"dashboard": DashboardModel{}})
+func TypeCheck(templates map[string]*template.Template, models Models) ([]Finding, error) {
+
+	funcs := render.GetFuncMap()
+
+	var findings []Finding
+	seen := make(map[string]bool)
+
+	names := make([]string, 0, len(models))
+	for name := range models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		set, ok := templates[name]
+		if !ok {
+			return nil, fmt.Errorf("template %s not found", name)
+		}
+
+		c := &typeChecker{
+			set:     set,
+			funcs:   funcs,
+			visited: make(map[string]bool),
+		}
+
+		model := reflect.TypeOf(models[name])
+
+		for _, entry := range entryTemplates(set) {
+			c.template(entry, model)
+		}
+
+		// Sets sharing a file report its problems once
+		for _, f := range c.findings {
+			key := f.Pos.String() + f.Message
+			if !seen[key] {
+				seen[key] = true
+				findings = append(findings, f)
+			}
+		}
+	}
+
+	sortFindings(findings)
+
+	return findings, nil
+}
+
+// entryTemplates returns the templates a set starts executing from:
+// its root when it has content, otherwise each file it was parsed from
+// (as with a set built on an empty, separately named root)
+func entryTemplates(set *template.Template) []*template.Template {
+
+	if set.Tree != nil && set.Tree.Root != nil {
+		return []*template.Template{set}
+	}
+
+	var entries []*template.Template
+	for _, t := range set.Templates() {
+		if t.Tree != nil && t.Tree.Root != nil && t.Name() == t.Tree.ParseName {
+			entries = append(entries, t)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	return entries
+}
+
+type variable struct {
+	name string
+	typ  reflect.Type
+}
+
+// typeChecker checks one set. A nil reflect.Type stands for a value whose
+// type is only known at execution time.
+type typeChecker struct {
+	set      *template.Template
+	funcs    map[string]interface{}
+	tree     *parse.Tree
+	cmd      *parse.CommandNode // the outermost command being checked
+	vars     []variable
+	visited  map[string]bool
+	findings []Finding
+}
+
+var (
+	errorType       = reflect.TypeOf((*error)(nil)).Elem()
+	reflectValueTyp = reflect.TypeOf(reflect.Value{})
+	boolType        = reflect.TypeOf(false)
+	intType         = reflect.TypeOf(0)
+	stringType      = reflect.TypeOf("")
+)
+
+// template checks *t* executed with a dot of type *dot*, once per type
+func (c *typeChecker) template(t *template.Template, dot reflect.Type) {
+
+	key := t.Name() + "\x00" + typeString(dot)
+	if c.visited[key] || t.Tree == nil || t.Tree.Root == nil {
+		return
+	}
+	c.visited[key] = true
+
+	tree, vars := c.tree, c.vars
+	c.tree, c.vars = t.Tree, []variable{{name: "$", typ: dot}}
+
+	c.list(t.Tree.Root, dot)
+
+	c.tree, c.vars = tree, vars
+}
+
+// report records a finding at *n*, quoting the command it is part of
+func (c *typeChecker) report(n parse.Node, what, format string, args ...interface{}) {
+
+	quoted := n
+	if c.cmd != nil {
+		quoted = c.cmd
+	}
+
+	_, context := c.tree.ErrorContext(quoted)
+	context = strings.TrimSuffix(strings.TrimPrefix(context, "{{"), "}}")
+
+	c.findings = append(c.findings, Finding{
+		Pos:      position(c.tree, n),
+		Template: c.tree.Name,
+		Func:     what,
+		Message:  fmt.Sprintf(format, args...),
+		Context:  context,
+	})
+}
+
+// list checks a block; variables declared in it go out of scope at its end
+func (c *typeChecker) list(list *parse.ListNode, dot reflect.Type) {
+
+	if list == nil {
+		return
+	}
+
+	mark := len(c.vars)
+	defer func() { c.vars = c.vars[:mark] }()
+
+	for _, n := range list.Nodes {
+		c.node(n, dot)
+	}
+}
+
+func (c *typeChecker) node(n parse.Node, dot reflect.Type) {
+
+	switch n := n.(type) {
+	case *parse.ActionNode:
+		c.pipe(n.Pipe, dot, true)
+
+	case *parse.IfNode:
+		mark := len(c.vars)
+		c.pipe(n.Pipe, dot, true)
+		c.list(n.List, dot)
+		c.list(n.ElseList, dot)
+		c.vars = c.vars[:mark]
+
+	case *parse.WithNode:
+		mark := len(c.vars)
+		value := c.pipe(n.Pipe, dot, true)
+		c.list(n.List, value)
+		c.list(n.ElseList, dot)
+		c.vars = c.vars[:mark]
+
+	case *parse.RangeNode:
+		mark := len(c.vars)
+		value := c.pipe(n.Pipe, dot, false)
+		key, elem := c.rangeTypes(n, value)
+
+		switch len(n.Pipe.Decl) {
+		case 1:
+			c.declare(n.Pipe.Decl[0], elem, n.Pipe.IsAssign)
+		case 2:
+			c.declare(n.Pipe.Decl[0], key, n.Pipe.IsAssign)
+			c.declare(n.Pipe.Decl[1], elem, n.Pipe.IsAssign)
+		}
+
+		c.list(n.List, elem)
+		c.vars = c.vars[:mark]
+		c.list(n.ElseList, dot)
+
+	case *parse.TemplateNode:
+		var value reflect.Type
+		if n.Pipe != nil {
+			value = c.pipe(n.Pipe, dot, false)
+		}
+
+		t := c.set.Lookup(n.Name)
+		if t == nil {
+			c.report(n, n.Name, "no such template")
+			return
+		}
+
+		c.template(t, value)
+	}
+}
+
+// pipe checks each command of *pipe* and returns the type of its result
+func (c *typeChecker) pipe(pipe *parse.PipeNode, dot reflect.Type, declare bool) reflect.Type {
+
+	if pipe == nil {
+		return nil
+	}
+
+	var value reflect.Type
+
+	outer := c.cmd
+	for i, cmd := range pipe.Cmds {
+		if outer == nil {
+			c.cmd = cmd
+		}
+		value = c.command(cmd, dot, value, i > 0)
+	}
+	c.cmd = outer
+
+	if declare {
+		for _, v := range pipe.Decl {
+			c.declare(v, value, pipe.IsAssign)
+		}
+	}
+
+	return value
+}
+
+func (c *typeChecker) declare(v *parse.VariableNode, typ reflect.Type, assign bool) {
+
+	if assign {
+		// $x = ... may change the type of an outer variable
+		for i := len(c.vars) - 1; i >= 0; i-- {
+			if c.vars[i].name == v.Ident[0] {
+				if c.vars[i].typ != typ {
+					c.vars[i].typ = nil
+				}
+				return
+			}
+		}
+	}
+
+	c.vars = append(c.vars, variable{name: v.Ident[0], typ: typ})
+}
+
+func (c *typeChecker) variable(name string) reflect.Type {
+	for i := len(c.vars) - 1; i >= 0; i-- {
+		if c.vars[i].name == name {
+			return c.vars[i].typ
+		}
+	}
+
+	return nil
+}
+
+// command checks one command; *final* is the value piped into it, if *piped*
+func (c *typeChecker) command(cmd *parse.CommandNode, dot, final reflect.Type, piped bool) reflect.Type {
+
+	args := cmd.Args[1:]
+
+	switch n := cmd.Args[0].(type) {
+	case *parse.IdentifierNode:
+		return c.call(cmd, n.Ident, args, dot, final, piped)
+	case *parse.FieldNode:
+		return c.fieldChain(cmd, dot, n.Ident, args, dot, final, piped)
+	case *parse.ChainNode:
+		return c.fieldChain(cmd, c.operand(n.Node, dot), n.Field, args, dot, final, piped)
+	case *parse.VariableNode:
+		v := c.variable(n.Ident[0])
+		if len(n.Ident) == 1 {
+			return v
+		}
+		return c.fieldChain(cmd, v, n.Ident[1:], args, dot, final, piped)
+	}
+
+	return c.operand(cmd.Args[0], dot)
+}
+
+// operand returns the type of an argument or other value without arguments of its own
+func (c *typeChecker) operand(n parse.Node, dot reflect.Type) reflect.Type {
+
+	switch n := n.(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.StringNode:
+		return stringType
+	case *parse.BoolNode:
+		return boolType
+	case *parse.NumberNode:
+		switch {
+		case n.IsInt:
+			return intType
+		case n.IsFloat:
+			return reflect.TypeOf(n.Float64)
+		}
+		return reflect.TypeOf(n.Complex128)
+	case *parse.PipeNode:
+		return c.pipe(n, dot, true)
+	case *parse.IdentifierNode:
+		return c.call(n, n.Ident, nil, dot, nil, false)
+	case *parse.FieldNode:
+		return c.fieldChain(n, dot, n.Ident, nil, dot, nil, false)
+	case *parse.ChainNode:
+		return c.fieldChain(n, c.operand(n.Node, dot), n.Field, nil, dot, nil, false)
+	case *parse.VariableNode:
+		v := c.variable(n.Ident[0])
+		if len(n.Ident) == 1 {
+			return v
+		}
+		return c.fieldChain(n, v, n.Ident[1:], nil, dot, nil, false)
+	}
+
+	return nil
+}
+
+// fieldChain resolves .A.B.C from *receiver*; the last element takes *args*
+// and the piped value when it is a method
+func (c *typeChecker) fieldChain(n parse.Node, receiver reflect.Type, chain []string, args []parse.Node, dot, final reflect.Type, piped bool) reflect.Type {
+
+	typ := receiver
+
+	for i, name := range chain {
+		last := i == len(chain)-1
+
+		if last {
+			typ = c.field(n, typ, name, args, dot, final, piped)
+		} else {
+			typ = c.field(n, typ, name, nil, dot, nil, false)
+		}
+
+		if typ == nil {
+			return nil
+		}
+	}
+
+	return typ
+}
+
+// field resolves method or field *name* of *typ*, the way text/template
+// does at execution time: methods first, through pointers, then struct
+// fields and map keys
+func (c *typeChecker) field(n parse.Node, typ reflect.Type, name string, args []parse.Node, dot, final reflect.Type, piped bool) reflect.Type {
+
+	if typ == nil {
+		return nil
+	}
+
+	receiver := typ
+	if typ.Kind() != reflect.Ptr && typ.Kind() != reflect.Interface {
+		receiver = reflect.PtrTo(typ)
+	}
+
+	if m, ok := receiver.MethodByName(name); ok {
+		skip := 1
+		if receiver.Kind() == reflect.Interface {
+			skip = 0
+		}
+		return c.checkCall(n, name, m.Type, skip, args, dot, final, piped)
+	}
+
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	hasArgs := len(args) > 0 || piped
+
+	switch typ.Kind() {
+	case reflect.Interface:
+		return nil
+
+	case reflect.Struct:
+		f, ok := typ.FieldByName(name)
+		if !ok {
+			c.report(n, "."+name, "can't evaluate field %s in type %s", name, receiverName(receiver))
+			return nil
+		}
+		if f.PkgPath != "" {
+			c.report(n, "."+name, "%s is an unexported field of struct type %s", name, receiverName(receiver))
+			return nil
+		}
+		if hasArgs {
+			c.report(n, "."+name, "%s has arguments but cannot be invoked as function", name)
+		}
+		return f.Type
+
+	case reflect.Map:
+		if typ.Key().Kind() != reflect.String {
+			c.report(n, "."+name, "can't evaluate field %s in type %s", name, receiverName(receiver))
+			return nil
+		}
+		if hasArgs {
+			c.report(n, "."+name, "%s is not a method but has arguments", name)
+		}
+		return typ.Elem()
+	}
+
+	c.report(n, "."+name, "can't evaluate field %s in type %s", name, receiverName(receiver))
+	return nil
+}
+
+// call checks a call of FuncMap or builtin function *name*
+func (c *typeChecker) call(n parse.Node, name string, args []parse.Node, dot, final reflect.Type, piped bool) reflect.Type {
+
+	if _, ok := builtins[name]; ok {
+		return c.builtin(name, args, dot, final, piped)
+	}
+
+	fn, ok := c.funcs[name]
+	if !ok || fn == nil {
+		return nil // e.g., the escapers html/template adds
+	}
+
+	ft := reflect.TypeOf(fn)
+	if ft.Kind() != reflect.Func {
+		return nil
+	}
+
+	return c.checkCall(n, name, ft, 0, args, dot, final, piped)
+}
+
+// checkCall checks the arguments of a function or method of type *ft*,
+// skipping *skip* leading parameters (the receiver), and returns its result
+func (c *typeChecker) checkCall(n parse.Node, name string, ft reflect.Type, skip int, args []parse.Node, dot, final reflect.Type, piped bool) reflect.Type {
+
+	numIn := ft.NumIn() - skip
+
+	count := len(args)
+	if piped {
+		count++
+	}
+
+	if ft.IsVariadic() {
+		if count < numIn-1 {
+			c.report(n, name, "wrong number of args: want at least %d got %d", numIn-1, count)
+		}
+	} else if count != numIn {
+		c.report(n, name, "wrong number of args: want %d got %d", numIn, count)
+	}
+
+	param := func(i int) reflect.Type {
+		i += skip
+		if ft.IsVariadic() && i >= ft.NumIn()-1 {
+			return ft.In(ft.NumIn() - 1).Elem()
+		}
+		if i < ft.NumIn() {
+			return ft.In(i)
+		}
+		return nil
+	}
+
+	for i, arg := range args {
+		c.checkArg(arg, name, i+1, param(i), dot)
+	}
+
+	if piped {
+		if want := param(len(args)); !assignable(final, want) {
+			c.report(n, name, "wrong type for piped value: expected %s; got %s", want, final)
+		}
+	}
+
+	switch {
+	case ft.NumOut() == 1:
+	case ft.NumOut() == 2 && ft.Out(1) == errorType:
+	default:
+		c.report(n, name, "can't call method/function %q with %d results", name, ft.NumOut())
+		return nil
+	}
+
+	return ft.Out(0)
+}
+
+func (c *typeChecker) checkArg(arg parse.Node, name string, index int, want reflect.Type, dot reflect.Type) {
+
+	if want == nil {
+		c.operand(arg, dot)
+		return
+	}
+
+	// Constants are converted to the parameter type, as at execution time
+	ok := true
+	switch a := arg.(type) {
+	case *parse.StringNode:
+		ok = want.Kind() == reflect.String || isAny(want)
+	case *parse.BoolNode:
+		ok = want.Kind() == reflect.Bool || isAny(want)
+	case *parse.NumberNode:
+		switch want.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			ok = a.IsInt || a.IsUint
+		case reflect.Float32, reflect.Float64:
+			ok = a.IsFloat
+		case reflect.Complex64, reflect.Complex128:
+			ok = a.IsComplex
+		default:
+			ok = isAny(want)
+		}
+	case *parse.NilNode:
+		switch want.Kind() {
+		case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		default:
+			ok = false
+		}
+	default:
+		got := c.operand(arg, dot)
+		if !assignable(got, want) {
+			c.report(arg, name, "wrong type for argument %d: expected %s; got %s", index, want, got)
+		}
+		return
+	}
+
+	if !ok {
+		c.report(arg, name, "wrong type for argument %d: expected %s; got %s", index, want, arg)
+	}
+}
+
+// builtin checks the arguments of a text/template builtin for field
+// errors and returns its result type where it is known
+func (c *typeChecker) builtin(name string, args []parse.Node, dot, final reflect.Type, piped bool) reflect.Type {
+
+	types := make([]reflect.Type, 0, len(args)+1)
+	for _, arg := range args {
+		types = append(types, c.operand(arg, dot))
+	}
+	if piped {
+		types = append(types, final)
+	}
+
+	switch name {
+	case "eq", "ne", "lt", "le", "gt", "ge", "not":
+		return boolType
+	case "len":
+		return intType
+	case "print", "printf", "println", "html", "js", "urlquery":
+		return stringType
+	case "slice":
+		if len(types) > 0 {
+			return types[0]
+		}
+	case "index":
+		if len(types) == 0 {
+			return nil
+		}
+		typ := types[0]
+		for range types[1:] {
+			typ = indexType(typ)
+		}
+		return typ
+	case "call":
+		if len(types) > 0 && types[0] != nil && types[0].Kind() == reflect.Func && types[0].NumOut() > 0 {
+			return types[0].Out(0)
+		}
+	}
+
+	return nil // and, or: either argument
+}
+
+// rangeTypes returns the key and element types of ranging over *typ*
+func (c *typeChecker) rangeTypes(n *parse.RangeNode, typ reflect.Type) (key, elem reflect.Type) {
+
+	if typ == nil {
+		return nil, nil
+	}
+
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		return intType, typ.Elem()
+	case reflect.Map:
+		return typ.Key(), typ.Elem()
+	case reflect.Chan:
+		return typ.Elem(), typ.Elem()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return typ, typ
+	case reflect.Func:
+		// Iterators: func(yield func(V) bool) or func(yield func(K, V) bool)
+		if typ.NumIn() == 1 && typ.In(0).Kind() == reflect.Func {
+			yield := typ.In(0)
+			switch yield.NumIn() {
+			case 1:
+				return yield.In(0), yield.In(0)
+			case 2:
+				return yield.In(0), yield.In(1)
+			}
+		}
+		return nil, nil
+	case reflect.Interface:
+		return nil, nil
+	}
+
+	c.report(n, "range", "range can't iterate over %s", typ)
+	return nil, nil
+}
+
+func indexType(typ reflect.Type) reflect.Type {
+
+	if typ == nil {
+		return nil
+	}
+
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return typ.Elem()
+	case reflect.String:
+		return reflect.TypeOf(byte(0))
+	}
+
+	return nil
+}
+
+// assignable reports whether a value of type *got* can be passed as *want*,
+// allowing the conversions text/template makes: through interfaces and
+// to or from pointers
+func assignable(got, want reflect.Type) bool {
+
+	switch {
+	case got == nil || want == nil:
+		return true
+	case want == reflectValueTyp || got.Kind() == reflect.Interface:
+		return true
+	case got.AssignableTo(want):
+		return true
+	case got.Kind() == reflect.Ptr && got.Elem().AssignableTo(want):
+		return true
+	case want.Kind() == reflect.Ptr && got.AssignableTo(want.Elem()):
+		return true
+	}
+
+	return false
+}
+
+func isAny(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface && t.NumMethod() == 0
+}
+
+// receiverName names the type a field was looked up in, without the
+// pointer added for method lookup
+func receiverName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.String()
+}
+
+func typeString(t reflect.Type) string {
+	if t == nil {
+		return "?"
+	}
+
+	return t.String()
+}
@@ -0,0 +1,98 @@
+package render
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock supplies the current time to the time-dependent helpers
+// (IsToday, InFuture, InPast) and to the Renderer.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock reads the wall clock.
+type SystemClock struct{}
+
+// Now returns time.Now()
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FixedClock always returns the same instant. Use it in tests
+// so pages that call isToday, inFuture or inPast render deterministically.
+type FixedClock time.Time
+
+// Now returns the fixed instant
+func (c FixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+// OffsetClock shifts another clock by *Offset*, e.g., for admin
+// "view as of date" previews. A nil *Base* uses the system clock.
+type OffsetClock struct {
+	Base   Clock
+	Offset time.Duration
+}
+
+// Now returns Base.Now() shifted by Offset
+func (c OffsetClock) Now() time.Time {
+	base := c.Base
+	if base == nil {
+		base = SystemClock{}
+	}
+
+	return base.Now().Add(c.Offset)
+}
+
+// AsOfClock returns a clock that starts at *asOf* and keeps ticking
+// from there, so a preview "as of" a date still shows the passage of time.
+func AsOfClock(asOf time.Time) Clock {
+	return OffsetClock{Offset: time.Until(asOf)}
+}
+
+var (
+	defaultClockMu sync.RWMutex
+	defaultClock   Clock = SystemClock{}
+)
+
+// SetClock replaces the package-wide clock used by the helpers when
+// no clock is supplied through a context. Passing nil restores the system clock.
+func SetClock(c Clock) {
+	if c == nil {
+		c = SystemClock{}
+	}
+
+	defaultClockMu.Lock()
+	defaultClock = c
+	defaultClockMu.Unlock()
+}
+
+// DefaultClock returns the package-wide clock
+func DefaultClock() Clock {
+	defaultClockMu.RLock()
+	defer defaultClockMu.RUnlock()
+
+	return defaultClock
+}
+
+type clockKey struct{}
+
+// WithClock returns a copy of *ctx* carrying *c*. The Renderer reads it
+// so a single request can be rendered as of a different time.
+func WithClock(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, clockKey{}, c)
+}
+
+// ClockFromContext returns the clock stored by WithClock,
+// falling back to DefaultClock.
+func ClockFromContext(ctx context.Context) Clock {
+	if ctx != nil {
+		if c, ok := ctx.Value(clockKey{}).(Clock); ok && c != nil {
+			return c
+		}
+	}
+
+	return DefaultClock()
+}
@@ -0,0 +1,334 @@
+// Command render previews a template set with fixture data, using the
+// same parsing and FuncMap as production, so views can be worked on
+// without running the application.
+//
+// Usage:
+//
+//	render [flags] -master views/master.html views/page.html ...
+//	render [flags] -sets sets.json -set authenticators
+//
+// The set is either a master template plus page files, or a set from a
+// JSON or YAML set file (see check.SetConfig). The model comes from a
+// JSON or YAML -fixture; fields are looked up by key, so {{.User.Name}}
+// reads {"User": {"Name": "..."}}.
+//
+// By default the page is written to stdout. With -serve :8080 it is
+// served instead, re-parsed on every request, and the browser reloads
+// whenever a file under -root or the fixture changes.
+//
+// Flags:
+//
+//	-root views      template root, watched for changes; translations are read from its locales directory
+//	-fixture file    model as .json, .yaml or .yml
+//	-lang en         locale to render in
+//	-now time        fixed current time, RFC 3339, for isToday and friends
+//	-serve addr      serve on addr instead of writing to stdout
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bjbigler/render"
+	"github.com/bjbigler/render/check"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+// previewName is the lookup name the previewed set is parsed under
+const previewName = "preview"
+
+type preview struct {
+	root    string
+	master  string
+	pages   []string
+	sets    string
+	set     string
+	fixture string
+	lang    language.Tag
+	clock   render.Clock
+}
+
+func main() {
+
+	var (
+		p    preview
+		lang string
+		now  string
+		addr string
+	)
+
+	flag.StringVar(&p.root, "root", "views", "template root, watched for changes")
+	flag.StringVar(&p.master, "master", "", "master template of the set")
+	flag.StringVar(&p.sets, "sets", "", "JSON or YAML file of base templates and sets")
+	flag.StringVar(&p.set, "set", "", "name of the set in -sets to render")
+	flag.StringVar(&p.fixture, "fixture", "", "JSON or YAML model")
+	flag.StringVar(&lang, "lang", "en", "locale to render in")
+	flag.StringVar(&now, "now", "", "fixed current time, RFC 3339")
+	flag.StringVar(&addr, "serve", "", "serve on this address instead of writing to stdout")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: render [flags] -master views/master.html views/page.html ...")
+		fmt.Fprintln(os.Stderr, "       render [flags] -sets sets.json -set name")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	p.pages = flag.Args()
+
+	if (p.master == "") == (p.sets == "") || (p.sets != "" && p.set == "") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var err error
+
+	if p.lang, err = language.Parse(lang); err != nil {
+		log.Fatalf("render: -lang: %v", err)
+	}
+
+	if now != "" {
+		t, err := time.Parse(time.RFC3339, now)
+		if err != nil {
+			log.Fatalf("render: -now: %v", err)
+		}
+		p.clock = render.FixedClock(t)
+	}
+
+	if addr != "" {
+		log.Fatal(p.serve(addr))
+	}
+
+	b, err := p.render(nil)
+	if err != nil {
+		log.Fatalf("render: %v", err)
+	}
+
+	os.Stdout.Write(b)
+}
+
+// renderer parses the set afresh, so every render sees the files as they are now
+func (p *preview) renderer() (*render.Renderer, error) {
+
+	var (
+		sets [][]string
+		base []string
+	)
+
+	if p.sets != "" {
+		config, err := check.LoadSetConfig(p.sets)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range config.Sets {
+			if len(s) > 0 && s[0] == p.set {
+				sets = [][]string{append([]string{previewName}, s[1:]...)}
+			}
+		}
+		if sets == nil {
+			return nil, fmt.Errorf("%s: no set %s", p.sets, p.set)
+		}
+		base = config.Base
+	} else {
+		sets = [][]string{append([]string{previewName}, p.pages...)}
+		base = []string{p.master}
+	}
+
+	templates, err := render.ParseTemplates(sets, base...)
+	if err != nil {
+		return nil, err
+	}
+
+	rd, err := render.NewRenderer(templates)
+	if err != nil {
+		return nil, err
+	}
+	rd.Clock = p.clock
+
+	if dir := filepath.Join(p.root, render.LocalesDir); isDir(dir) {
+		if rd.Catalog, err = render.LoadCatalog(dir, language.English); err != nil {
+			return nil, err
+		}
+	}
+
+	return rd, nil
+}
+
+// render executes the set with the fixture; *r* may be nil
+func (p *preview) render(r *http.Request) ([]byte, error) {
+
+	rd, err := p.renderer()
+	if err != nil {
+		return nil, err
+	}
+
+	model, err := p.model()
+	if err != nil {
+		return nil, err
+	}
+
+	if r == nil {
+		r, _ = http.NewRequest(http.MethodGet, "/", nil)
+	}
+	r = r.WithContext(render.WithLocale(r.Context(), p.lang))
+
+	out, err := rd.ToHTML(r, previewName, model)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(out), nil
+}
+
+func (p *preview) model() (interface{}, error) {
+
+	if p.fixture == "" {
+		return map[string]interface{}{}, nil
+	}
+
+	b, err := os.ReadFile(p.fixture)
+	if err != nil {
+		return nil, err
+	}
+
+	var model interface{}
+
+	switch filepath.Ext(p.fixture) {
+	case ".json":
+		err = json.Unmarshal(b, &model)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &model)
+	default:
+		return nil, fmt.Errorf("%s: fixtures must be .json, .yaml or .yml", p.fixture)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", p.fixture, err)
+	}
+
+	return model, nil
+}
+
+// reloadPath streams an event whenever the watched files change
+const reloadPath = "/_render/reload"
+
+const reloadScript = `<script>new EventSource("` + reloadPath + `").onmessage = function () { location.reload() }</script>`
+
+func (p *preview) serve(addr string) error {
+
+	http.HandleFunc(reloadPath, p.reload)
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		b, err := p.render(r)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			b = []byte("<!DOCTYPE html><pre>" + template.HTMLEscapeString(err.Error()) + "</pre>")
+		}
+
+		render.BytesToBrowser(w, injectReload(b))
+	})
+
+	log.Printf("render: serving %s on http://%s/", p.describe(), displayAddr(addr))
+
+	return http.ListenAndServe(addr, nil)
+}
+
+// reload holds the connection open and sends an event when a watched file changes
+func (p *preview) reload(w http.ResponseWriter, r *http.Request) {
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher.Flush()
+
+	last := p.modified()
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if m := p.modified(); m.After(last) {
+				fmt.Fprint(w, "data: reload\n\n")
+				flusher.Flush()
+				return
+			}
+		}
+	}
+}
+
+// modified returns the latest modification time of the watched files
+func (p *preview) modified() time.Time {
+
+	var latest time.Time
+
+	note := func(path string) {
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	filepath.WalkDir(p.root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			note(path)
+		}
+		return nil
+	})
+
+	for _, path := range append([]string{p.master, p.sets, p.fixture}, p.pages...) {
+		if path != "" {
+			note(path)
+		}
+	}
+
+	return latest
+}
+
+func (p *preview) describe() string {
+	if p.sets != "" {
+		return "set " + p.set
+	}
+
+	return strings.Join(append([]string{p.master}, p.pages...), " + ")
+}
+
+// injectReload adds the live reload script before </body>, or at the end
+func injectReload(b []byte) []byte {
+
+	i := bytes.LastIndex(bytes.ToLower(b), []byte("</body>"))
+	if i < 0 {
+		return append(b, reloadScript...)
+	}
+
+	return append(b[:i:i], append([]byte(reloadScript), b[i:]...)...)
+}
+
+func displayAddr(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "localhost" + addr
+	}
+
+	return addr
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
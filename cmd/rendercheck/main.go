@@ -0,0 +1,187 @@
+// Command rendercheck statically checks the HTML templates of an
+// application built on github.com/bjbigler/render.
+//
+// Usage:
+//
+//	rendercheck lint [-root views] [file.html ...]
+//	rendercheck graph -sets sets.json [-root views] [-dot] [-unused]
+//	rendercheck bundle -sets sets.json -o views.bundle
+//
+// lint reports calls to safe, htmlEscape, renderFragment, newLineToBR,
+// marshal, arrayToQS and unsafeRawHTML whose argument comes from model
+// data, with file:line:col positions. It exits with status 1 when
+// anything is found, so it can gate code review.
+//
+// graph parses the template sets described by a JSON or YAML file
+// (see check.SetConfig) and reports {{template}} calls to templates the
+// set doesn't define and templates defined in more than one file of a
+// set, exiting with status 1 if there are any. With -unused it also lists
+// {{define}}s nothing calls, files under -root no set includes and
+// FuncMap functions no template calls. With -dot it prints the graph in
+// Graphviz format instead.
+//
+// bundle parses the same sets and, if they are valid, writes them to a
+// render.Bundle file for the application to embed and load with
+// render.LoadBundle. Run it at build time, e.g., from go:generate.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bjbigler/render"
+	"github.com/bjbigler/render/check"
+)
+
+func main() {
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	found := false
+
+	switch os.Args[1] {
+	case "lint":
+		found, err = lint(os.Args[2:])
+	case "graph":
+		found, err = graph(os.Args[2:])
+	case "bundle":
+		err = bundle(os.Args[2:])
+	default:
+		usage()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "rendercheck:", err)
+		os.Exit(2)
+	}
+
+	if found {
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: rendercheck lint [-root views] [file.html ...]")
+	fmt.Fprintln(os.Stderr, "       rendercheck graph -sets sets.json [-root views] [-dot] [-unused]")
+	fmt.Fprintln(os.Stderr, "       rendercheck bundle -sets sets.json -o views.bundle")
+	os.Exit(2)
+}
+
+func lint(args []string) (bool, error) {
+
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	root := fs.String("root", "views", "template root to scan when no files are given")
+	fs.Parse(args)
+
+	var (
+		findings []check.Finding
+		err      error
+	)
+
+	if fs.NArg() > 0 {
+		findings, err = check.LintFiles(fs.Args()...)
+	} else {
+		findings, err = check.LintDir(*root)
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, f := range findings {
+		fmt.Println(f)
+	}
+
+	return len(findings) > 0, nil
+}
+
+func graph(args []string) (bool, error) {
+
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	sets := fs.String("sets", "", "JSON or YAML file listing the base templates and sets")
+	root := fs.String("root", "views", "template root to look for unused files in")
+	dot := fs.Bool("dot", false, "print the graph in Graphviz format")
+	unused := fs.Bool("unused", false, "also list unused defines, files and functions")
+	fs.Parse(args)
+
+	if *sets == "" {
+		usage()
+	}
+
+	config, err := check.LoadSetConfig(*sets)
+	if err != nil {
+		return false, err
+	}
+
+	g, err := check.BuildGraph(config.Sets, config.Base...)
+	if err != nil {
+		return false, err
+	}
+
+	if *dot {
+		return false, g.WriteDot(os.Stdout)
+	}
+
+	problems := append(g.Undefined(), g.Duplicates()...)
+	for _, f := range problems {
+		fmt.Println(f)
+	}
+
+	if *unused {
+		for _, f := range g.UnusedDefines() {
+			fmt.Println(f)
+		}
+
+		files, err := g.UnusedFiles(*root)
+		if err != nil {
+			return false, err
+		}
+		for _, path := range files {
+			fmt.Printf("%s: not included in any set\n", path)
+		}
+
+		for _, name := range g.UnusedFuncs(render.GetFuncMap()) {
+			fmt.Printf("%s: function not called by any template\n", name)
+		}
+	}
+
+	return len(problems) > 0, nil
+}
+
+func bundle(args []string) error {
+
+	fs := flag.NewFlagSet("bundle", flag.ExitOnError)
+	sets := fs.String("sets", "", "JSON or YAML file listing the base templates and sets")
+	out := fs.String("o", "views.bundle", "bundle file to write")
+	fs.Parse(args)
+
+	if *sets == "" {
+		usage()
+	}
+
+	config, err := check.LoadSetConfig(*sets)
+	if err != nil {
+		return err
+	}
+
+	b, err := render.NewBundle(config.Sets, config.Base...)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+
+	if _, err := b.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,339 @@
+package render
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Encoder is a Content-Encoding the Compression middleware can apply
+type Encoder struct {
+	Name string // token in Accept-Encoding and Content-Encoding, e.g., "gzip" or "br"
+	New  func(w io.Writer) io.WriteCloser
+}
+
+// GzipEncoder compresses with the standard library's gzip
+var GzipEncoder = Encoder{
+	Name: "gzip",
+	New: func(w io.Writer) io.WriteCloser {
+		return gzip.NewWriter(w)
+	},
+}
+
+// DefaultSkipTypes are content types, or prefixes of them, that are
+// already compressed or must not be buffered
+var DefaultSkipTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"application/pdf",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/x-7z-compressed",
+	"application/vnd.openxmlformats-officedocument.", // xlsx, docx, pptx
+	"application/octet-stream",
+	"application/octect-stream", // as sent by XlsxToBrowser
+	"text/event-stream",
+}
+
+// Compression compresses responses for clients that accept it, so every
+// writer in this package (StringToBrowser, JSONToBrowser, CsvToBrowser,
+// Renderer.Render, ...) benefits without change:
+//
+//	http.ListenAndServe(addr, render.NewCompression().Middleware(mux))
+//
+// Brotli is not in the standard library; plug in a pure-Go encoder,
+// e.g., github.com/andybalholm/brotli, ahead of gzip to prefer it:
+//
+//	c := render.NewCompression()
+//	c.Encoders = append([]render.Encoder{{
+//		Name: "br",
+//		New:  func(w io.Writer) io.WriteCloser { return brotli.NewWriterLevel(w, 5) },
+//	}}, c.Encoders...)
+type Compression struct {
+	// MinSize is the smallest body worth compressing, in bytes
+	MinSize int
+
+	// Encoders in order of preference, when the client accepts several equally
+	Encoders []Encoder
+
+	// SkipTypes are never compressed; see DefaultSkipTypes
+	SkipTypes []string
+}
+
+// NewCompression returns gzip compression of bodies of 1 KB or more,
+// skipping DefaultSkipTypes
+func NewCompression() *Compression {
+	return &Compression{
+		MinSize:   1024,
+		Encoders:  []Encoder{GzipEncoder},
+		SkipTypes: DefaultSkipTypes,
+	}
+}
+
+// Middleware compresses the responses of *next*
+func (c *Compression) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{
+			ResponseWriter: w,
+			c:              c,
+			encoder:        c.negotiate(r.Header.Get("Accept-Encoding")),
+			status:         http.StatusOK,
+		}
+		defer cw.Close()
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiate picks the encoder *acceptEncoding* rates highest, with ties
+// going to the earlier encoder; nil means identity
+func (c *Compression) negotiate(acceptEncoding string) *Encoder {
+
+	if acceptEncoding == "" {
+		return nil
+	}
+
+	quality := make(map[string]float64)
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.TrimSpace(key) == "q" {
+				if v, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		quality[name] = q
+	}
+
+	var (
+		best  *Encoder
+		bestQ float64
+	)
+
+	for i := range c.Encoders {
+		e := &c.Encoders[i]
+
+		q, ok := quality[strings.ToLower(e.Name)]
+		if !ok {
+			q, ok = quality["*"]
+		}
+
+		if ok && q > bestQ {
+			best, bestQ = e, q
+		}
+	}
+
+	return best
+}
+
+func (c *Compression) skip(contentType string) bool {
+
+	contentType = strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+
+	for _, t := range c.SkipTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compressWriter buffers the start of the body until it knows whether
+// compressing is worthwhile, then writes through the encoder or directly
+type compressWriter struct {
+	http.ResponseWriter
+	c       *Compression
+	encoder *Encoder
+
+	status      int
+	wroteHeader bool // WriteHeader was called by the handler
+	decided     bool
+	buf         []byte
+	enc         io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.decided || cw.wroteHeader {
+		return
+	}
+
+	cw.status = status
+	cw.wroteHeader = true
+
+	// Bodiless and informational responses pass straight through
+	if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified {
+		cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < cw.c.MinSize {
+			return len(p), nil
+		}
+		if err := cw.start(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if cw.enc != nil {
+		return cw.enc.Write(p)
+	}
+
+	return cw.ResponseWriter.Write(p)
+}
+
+// start decides on the buffered body and writes it
+func (cw *compressWriter) start() error {
+
+	cw.decide(len(cw.buf) >= cw.c.MinSize)
+
+	buf := cw.buf
+	cw.buf = nil
+
+	if len(buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+
+	return err
+}
+
+// decide sets the headers for compressing, if *large* enough and
+// allowed, and sends the status
+func (cw *compressWriter) decide(large bool) {
+
+	cw.decided = true
+	h := cw.ResponseWriter.Header()
+
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		// Compressed bytes would defeat net/http's sniffing
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+
+	negotiable := h.Get("Content-Encoding") == "" &&
+		!cw.c.skip(h.Get("Content-Type")) &&
+		!strings.Contains(h.Get("Cache-Control"), "no-transform") &&
+		cw.status != http.StatusPartialContent
+
+	if negotiable {
+		addVary(h, "Accept-Encoding")
+	}
+
+	if negotiable && large && cw.encoder != nil && cw.status >= 200 &&
+		cw.status != http.StatusNoContent && cw.status != http.StatusNotModified {
+		h.Set("Content-Encoding", cw.encoder.Name)
+		h.Del("Content-Length")
+
+		// The encoded bytes differ from the identity ones, so a strong
+		// validator no longer identifies them
+		if etag := h.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			h.Set("ETag", "W/"+etag)
+		}
+
+		cw.enc = cw.encoder.New(cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+}
+
+// addVary adds *value* to the Vary header unless it is already listed
+func addVary(h http.Header, value string) {
+	for _, v := range h.Values("Vary") {
+		for _, field := range strings.Split(v, ",") {
+			field = strings.TrimSpace(field)
+			if field == "*" || strings.EqualFold(field, value) {
+				return
+			}
+		}
+	}
+
+	h.Add("Vary", value)
+}
+
+// Flush sends what has been written so far, deciding on compression with
+// whatever is buffered
+func (cw *compressWriter) Flush() {
+
+	if !cw.decided {
+		if !cw.wroteHeader && len(cw.buf) == 0 {
+			return // nothing to send yet
+		}
+		cw.start()
+	}
+
+	if f, ok := cw.enc.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close finishes the response after the handler returns
+func (cw *compressWriter) Close() error {
+
+	if !cw.decided {
+		if !cw.wroteHeader && len(cw.buf) == 0 {
+			return nil // the handler wrote nothing; let net/http send its default
+		}
+		if err := cw.start(); err != nil {
+			return err
+		}
+	}
+
+	if cw.enc != nil {
+		return cw.enc.Close()
+	}
+
+	return nil
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Hijack passes through for websockets, which are never compressed
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := cw.ResponseWriter.(http.Hijacker); ok {
+		cw.decided = true
+		return h.Hijack()
+	}
+
+	return nil, nil, http.ErrNotSupported
+}
@@ -0,0 +1,220 @@
+package render
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompressionNegotiate(t *testing.T) {
+
+	br := Encoder{Name: "br", New: GzipEncoder.New}
+	c := &Compression{Encoders: []Encoder{br, GzipEncoder}}
+
+	tests := []struct {
+		accept string
+		want   string // "" for identity
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"GZIP", "gzip"},
+		{"gzip;q=0", ""},
+		{"gzip; q=0.0", ""},
+		{"identity", ""},
+		{"*", "br"},
+		{"*;q=0", ""},
+		{"gzip;q=0, *", "br"},
+		{"br;q=0, *", "gzip"},
+		{"br;q=0, gzip;q=0, *", ""},
+		{"gzip, br", "br"}, // ties go to the first encoder
+		{"br;q=0.5, gzip", "gzip"},
+		{"br;q=1.0, gzip;q=0.8", "br"},
+		{"deflate", ""},
+		{"gzip;q=bad", "gzip"},
+	}
+
+	for _, tt := range tests {
+		got := ""
+		if e := c.negotiate(tt.accept); e != nil {
+			got = e.Name
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+
+	large := strings.Repeat("<p>compress me</p>\n", 100)
+
+	tests := []struct {
+		name     string
+		method   string
+		accept   string
+		status   int
+		headers  map[string]string
+		vary     []string // set by the handler
+		body     []string // written in pieces
+		encoding string
+		wantVary []string
+		etag     string
+	}{
+		{
+			name: "large", accept: "gzip", body: []string{large},
+			encoding: "gzip", wantVary: []string{"Accept-Encoding"},
+		},
+		{
+			name: "small", accept: "gzip", body: []string{"<p>hi</p>"},
+			wantVary: []string{"Accept-Encoding"},
+		},
+		{
+			name: "exactly MinSize", accept: "gzip", body: []string{strings.Repeat("a", 1024)},
+			encoding: "gzip", wantVary: []string{"Accept-Encoding"},
+		},
+		{
+			name: "one byte short", accept: "gzip", body: []string{strings.Repeat("a", 1023)},
+			wantVary: []string{"Accept-Encoding"},
+		},
+		{
+			name: "written in pieces", accept: "gzip", body: []string{strings.Repeat("a", 600), strings.Repeat("b", 600), "c"},
+			encoding: "gzip", wantVary: []string{"Accept-Encoding"},
+		},
+		{
+			name: "refused", accept: "gzip;q=0", body: []string{large},
+			wantVary: []string{"Accept-Encoding"},
+		},
+		{
+			name: "not accepted", body: []string{large},
+			wantVary: []string{"Accept-Encoding"},
+		},
+		{
+			name: "skipped type", accept: "gzip", body: []string{large},
+			headers: map[string]string{"Content-Type": "image/svg+xml"},
+		},
+		{
+			name: "skipped type with parameters", accept: "gzip", body: []string{large},
+			headers: map[string]string{"Content-Type": "Application/PDF; name=a.pdf"},
+		},
+		{
+			name: "already encoded", accept: "gzip", body: []string{large},
+			headers: map[string]string{"Content-Encoding": "br"}, encoding: "br",
+		},
+		{
+			name: "no-transform", accept: "gzip", body: []string{large},
+			headers: map[string]string{"Cache-Control": "public, no-transform"},
+		},
+		{
+			name: "head", method: http.MethodHead, accept: "gzip",
+			headers: map[string]string{"Content-Type": "text/html; charset=utf-8"},
+		},
+		{
+			name: "no content", accept: "gzip", status: http.StatusNoContent,
+			wantVary: []string{"Accept-Encoding"},
+			headers:  map[string]string{"Content-Type": "text/html; charset=utf-8"},
+		},
+		{
+			name: "not modified", accept: "gzip", status: http.StatusNotModified,
+			headers:  map[string]string{"Content-Type": "text/html; charset=utf-8", "ETag": `"v1"`},
+			wantVary: []string{"Accept-Encoding"}, etag: `"v1"`,
+		},
+		{
+			name: "strong etag", accept: "gzip", body: []string{large},
+			headers:  map[string]string{"ETag": `"v1"`},
+			encoding: "gzip", wantVary: []string{"Accept-Encoding"}, etag: `W/"v1"`,
+		},
+		{
+			name: "weak etag", accept: "gzip", body: []string{large},
+			headers:  map[string]string{"ETag": `W/"v1"`},
+			encoding: "gzip", wantVary: []string{"Accept-Encoding"}, etag: `W/"v1"`,
+		},
+		{
+			name: "identity keeps a strong etag", body: []string{large},
+			headers:  map[string]string{"ETag": `"v1"`},
+			wantVary: []string{"Accept-Encoding"}, etag: `"v1"`,
+		},
+		{
+			name: "vary merged", accept: "gzip", body: []string{large}, vary: []string{"Accept-Language"},
+			encoding: "gzip", wantVary: []string{"Accept-Language", "Accept-Encoding"},
+		},
+		{
+			name: "vary already listed", accept: "gzip", body: []string{large}, vary: []string{"Cookie, accept-encoding"},
+			encoding: "gzip", wantVary: []string{"Cookie, accept-encoding"},
+		},
+		{
+			name: "vary star", accept: "gzip", body: []string{large}, vary: []string{"*"},
+			encoding: "gzip", wantVary: []string{"*"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewCompression().Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.headers {
+					w.Header().Set(k, v)
+				}
+				for _, v := range tt.vary {
+					w.Header().Add("Vary", v)
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				for _, b := range tt.body {
+					io.WriteString(w, b)
+				}
+			}))
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept-Encoding", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, r)
+
+			want := http.StatusOK
+			if tt.status != 0 {
+				want = tt.status
+			}
+			if w.Code != want {
+				t.Errorf("status = %d, want %d", w.Code, want)
+			}
+
+			if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.encoding)
+			}
+			if got := w.Header().Values("Vary"); strings.Join(got, "|") != strings.Join(tt.wantVary, "|") {
+				t.Errorf("Vary = %q, want %q", got, tt.wantVary)
+			}
+			if got := w.Header().Get("ETag"); got != tt.etag {
+				t.Errorf("ETag = %q, want %q", got, tt.etag)
+			}
+			if w.Header().Get("Content-Type") == "" && len(tt.body) > 0 {
+				t.Error("no Content-Type")
+			}
+
+			body := w.Body.String()
+			if tt.encoding == "gzip" {
+				zr, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				b, err := io.ReadAll(zr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = string(b)
+			}
+			if want := strings.Join(tt.body, ""); body != want {
+				t.Errorf("body is %d bytes, want %d", len(body), len(want))
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package render
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCSPHeader(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		csp       *CSP
+		nonce     string
+		wantName  string
+		wantValue string
+	}{
+		{
+			name:      "zero value",
+			csp:       (&CSP{}).Set("default-src", "'self'").Add("img-src", "data:"),
+			wantName:  "Content-Security-Policy",
+			wantValue: "default-src 'self'; img-src data:",
+		},
+		{
+			name:      "report only literal",
+			csp:       (&CSP{ReportOnly: true}).Add("script-src", "'self'", NonceSource),
+			nonce:     "abc",
+			wantName:  "Content-Security-Policy-Report-Only",
+			wantValue: "script-src 'self' 'nonce-abc'",
+		},
+		{
+			name:      "empty",
+			csp:       &CSP{},
+			wantName:  "Content-Security-Policy",
+			wantValue: "",
+		},
+		{
+			name:      "order and replace",
+			csp:       NewCSP().Set("a", "1").Set("b", "2").Set("a", "3").Add("b", "4"),
+			wantName:  "Content-Security-Policy",
+			wantValue: "a 3; b 2 4",
+		},
+		{
+			name:      "nonce without value",
+			csp:       NewCSP().Set("style-src", NonceSource, "'self'"),
+			wantName:  "Content-Security-Policy",
+			wantValue: "style-src 'self'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, value := tt.csp.Header(tt.nonce)
+			if name != tt.wantName || value != tt.wantValue {
+				t.Errorf("Header = %s: %s\nwant %s: %s", name, value, tt.wantName, tt.wantValue)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	(&CSP{}).Set("object-src", "'none'").Apply(w, "")
+	if got := w.Header().Get("Content-Security-Policy"); got != "object-src 'none'" {
+		t.Errorf("Apply set %q", got)
+	}
+}
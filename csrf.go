@@ -0,0 +1,321 @@
+package render
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"net/http"
+)
+
+// Default names used by CSRF when its fields are empty
+const (
+	CSRFCookieName = "_csrf"
+	CSRFHeaderName = "X-CSRF-Token"
+	CSRFFieldName  = "csrf_token"
+)
+
+// ErrCSRFToken is returned when a request carries no valid CSRF token
+var ErrCSRFToken = errors.New("invalid or missing CSRF token")
+
+// ErrNoCSRF is returned by csrfToken and csrfField, and CSRFToken and
+// CSRFField, when the request did not pass through CSRF.Middleware or
+// the page is not rendered by a Renderer, so a form would post without
+// a token and be rejected
+var ErrNoCSRF = errors.New("no CSRF token; render with a Renderer behind CSRF.Middleware")
+
+// CSRFSecret supplies the key that signs CSRF tokens. Load it from
+// your secret store in production; use StaticCSRFSecret in tests.
+type CSRFSecret interface {
+	CSRFKey(ctx context.Context) ([]byte, error)
+}
+
+// StaticCSRFSecret is a fixed key
+type StaticCSRFSecret []byte
+
+// CSRFKey returns the fixed key
+func (s StaticCSRFSecret) CSRFKey(ctx context.Context) ([]byte, error) {
+	if len(s) == 0 {
+		return nil, errors.New("empty CSRF key")
+	}
+
+	return s, nil
+}
+
+// CSRF protects form posts and fetch calls with signed double-submit
+// tokens. Middleware gives each browser a random id in a cookie; the token
+// is an HMAC of that id, masked afresh on every render so it doesn't
+// leak through compression. Unsafe requests (anything but GET, HEAD,
+// OPTIONS and TRACE) must echo the token in the X-CSRF-Token header or
+// the csrf_token form field.
+//
+//	csrf := &render.CSRF{Secret: render.StaticCSRFSecret(key), Secure: true}
+//	http.ListenAndServe(":8080", csrf.Middleware(mux))
+//
+// In templates, {{csrfField}} emits the hidden input for forms and
+// {{csrfToken}} the bare token, e.g., for a <meta> tag read by doPostFetch.
+//
+// Set SessionID to tie tokens to the signed-in session, so a cookie
+// planted from a sibling subdomain can't be paired with a token the
+// attacker obtained for their own session:
+//
+//	csrf.SessionID = func(r *http.Request) string { return sessions.ID(r) }
+type CSRF struct {
+	Secret CSRFSecret
+
+	// SessionID, when set, returns the id of the session *r* belongs to,
+	// or "" when there is none; it is signed into every token. Tokens
+	// rendered before sign-in stop verifying after it.
+	SessionID func(r *http.Request) string
+
+	CookieName string // defaults to CSRFCookieName
+	HeaderName string // defaults to CSRFHeaderName
+	FieldName  string // defaults to CSRFFieldName
+
+	// Secure marks the cookie Secure; set it whenever the site is served over HTTPS
+	Secure bool
+
+	// ErrorHandler answers rejected requests. Nil sends 403 with the
+	// same JSON ReportError produces.
+	ErrorHandler http.Handler
+}
+
+type csrfState struct {
+	csrf    *CSRF
+	id      []byte
+	session string
+}
+
+type csrfKey struct{}
+
+// Middleware issues the CSRF cookie and rejects unsafe requests without a valid token
+func (c *CSRF) Middleware(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		id, err := c.cookieID(r)
+		if err != nil {
+			id = make([]byte, 32)
+			if _, err := rand.Read(id); err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			http.SetCookie(w, &http.Cookie{
+				Name:     c.cookieName(),
+				Value:    base64.RawURLEncoding.EncodeToString(id),
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   c.Secure,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		state := &csrfState{csrf: c, id: id, session: c.sessionID(r)}
+		r = r.WithContext(context.WithValue(r.Context(), csrfKey{}, state))
+
+		if !csrfSafeMethod(r.Method) {
+			if err := c.Verify(r); err != nil {
+				c.reject(w, r)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Verify checks the token sent with *r* against its CSRF cookie
+func (c *CSRF) Verify(r *http.Request) error {
+
+	id, err := c.cookieID(r)
+	if err != nil {
+		return ErrCSRFToken
+	}
+
+	token := r.Header.Get(c.headerName())
+	if token == "" {
+		token = r.PostFormValue(c.fieldName())
+	}
+
+	masked, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(masked) != 2*sha256.Size {
+		return ErrCSRFToken
+	}
+
+	expected, err := c.sign(r.Context(), id, c.sessionID(r))
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare(unmask(masked), expected) != 1 {
+		return ErrCSRFToken
+	}
+
+	return nil
+}
+
+// CSRFToken returns a fresh token for the request, or ErrNoCSRF when
+// the request did not pass through CSRF.Middleware
+func CSRFToken(r *http.Request) (string, error) {
+	return csrfTokenFromContext(requestContext(r))
+}
+
+// CSRFField returns the hidden form input carrying the token of *r*
+func CSRFField(r *http.Request) (template.HTML, error) {
+	return csrfFieldFromContext(requestContext(r))
+}
+
+func csrfTokenFromContext(ctx context.Context) (string, error) {
+
+	state, ok := ctx.Value(csrfKey{}).(*csrfState)
+	if !ok {
+		return "", ErrNoCSRF
+	}
+
+	signature, err := state.csrf.sign(ctx, state.id, state.session)
+	if err != nil {
+		return "", err
+	}
+
+	masked, err := mask(signature)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(masked), nil
+}
+
+func csrfFieldFromContext(ctx context.Context) (template.HTML, error) {
+
+	state, ok := ctx.Value(csrfKey{}).(*csrfState)
+	if !ok {
+		return template.HTML(""), ErrNoCSRF
+	}
+
+	token, err := csrfTokenFromContext(ctx)
+	if err != nil {
+		return template.HTML(""), err
+	}
+
+	return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(state.csrf.fieldName()) +
+		`" value="` + token + `">`), nil
+}
+
+// sign returns the HMAC of the cookie *id* and, when SessionID is set, the *session*
+func (c *CSRF) sign(ctx context.Context, id []byte, session string) ([]byte, error) {
+
+	if c.Secret == nil {
+		return nil, errors.New("CSRF.Secret is not set")
+	}
+
+	key, err := c.Secret.CSRFKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hmac.New(sha256.New, key)
+	h.Write(id) // always 32 bytes, so the session can follow unseparated
+	h.Write([]byte(session))
+
+	return h.Sum(nil), nil
+}
+
+func (c *CSRF) cookieID(r *http.Request) ([]byte, error) {
+
+	cookie, err := r.Cookie(c.cookieName())
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil || len(id) != 32 {
+		return nil, ErrCSRFToken
+	}
+
+	return id, nil
+}
+
+func (c *CSRF) reject(w http.ResponseWriter, r *http.Request) {
+
+	if c.ErrorHandler != nil {
+		c.ErrorHandler.ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	w.Write([]byte(`{"statusCode":0,"error":"` + ErrCSRFToken.Error() + `"}`))
+}
+
+func (c *CSRF) sessionID(r *http.Request) string {
+	if c.SessionID == nil {
+		return ""
+	}
+
+	return c.SessionID(r)
+}
+
+func (c *CSRF) cookieName() string {
+	if c.CookieName == "" {
+		return CSRFCookieName
+	}
+
+	return c.CookieName
+}
+
+func (c *CSRF) headerName() string {
+	if c.HeaderName == "" {
+		return CSRFHeaderName
+	}
+
+	return c.HeaderName
+}
+
+func (c *CSRF) fieldName() string {
+	if c.FieldName == "" {
+		return CSRFFieldName
+	}
+
+	return c.FieldName
+}
+
+func csrfSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+
+	return false
+}
+
+// mask returns a one-time pad followed by *token* XORed with it
+func mask(token []byte) ([]byte, error) {
+	pad := make([]byte, len(token))
+	if _, err := rand.Read(pad); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 2*len(token))
+	copy(out, pad)
+	for i := range token {
+		out[len(token)+i] = token[i] ^ pad[i]
+	}
+
+	return out, nil
+}
+
+func unmask(masked []byte) []byte {
+	n := len(masked) / 2
+
+	out := make([]byte, n)
+	for i := 0; i < n; i++ {
+		out[i] = masked[i] ^ masked[n+i]
+	}
+
+	return out
+}
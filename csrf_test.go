@@ -0,0 +1,104 @@
+package render
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// issue runs a GET through *c* and returns the cookie and a token for it
+func issue(t *testing.T, c *CSRF, session string) (*http.Cookie, string) {
+	t.Helper()
+
+	var token string
+	h := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		if token, err = CSRFToken(r); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Session", session)
+	h.ServeHTTP(w, r)
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("got %d cookies, want 1", len(cookies))
+	}
+
+	return cookies[0], token
+}
+
+func TestCSRFVerify(t *testing.T) {
+
+	c := &CSRF{
+		Secret:    StaticCSRFSecret("key"),
+		SessionID: func(r *http.Request) string { return r.Header.Get("X-Session") },
+	}
+
+	cookie, token := issue(t, c, "s1")
+	other, _ := issue(t, c, "s1")
+
+	tests := []struct {
+		name    string
+		cookie  *http.Cookie
+		token   string
+		session string
+		want    error
+	}{
+		{"valid", cookie, token, "s1", nil},
+		{"other session", cookie, token, "s2", ErrCSRFToken},
+		{"signed out", cookie, token, "", ErrCSRFToken},
+		{"other cookie", other, token, "s1", ErrCSRFToken},
+		{"no cookie", nil, token, "s1", ErrCSRFToken},
+		{"no token", cookie, "", "s1", ErrCSRFToken},
+		{"garbled token", cookie, token[1:], "s1", ErrCSRFToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.cookie != nil {
+				r.AddCookie(tt.cookie)
+			}
+			r.Header.Set(CSRFHeaderName, tt.token)
+			r.Header.Set("X-Session", tt.session)
+
+			if err := c.Verify(r); !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSRFWithoutMiddleware(t *testing.T) {
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	if _, err := CSRFToken(r); !errors.Is(err, ErrNoCSRF) {
+		t.Errorf("CSRFToken = %v, want ErrNoCSRF", err)
+	}
+	if _, err := CSRFField(r); !errors.Is(err, ErrNoCSRF) {
+		t.Errorf("CSRFField = %v, want ErrNoCSRF", err)
+	}
+
+	for _, name := range []string{"csrfToken", "csrfField"} {
+		var err error
+		switch f := GetFuncMap()[name].(type) {
+		case func() (string, error):
+			_, err = f()
+		case func() (template.HTML, error):
+			_, err = f()
+		default:
+			t.Fatalf("%s: unexpected signature %T", name, f)
+		}
+
+		if !errors.Is(err, ErrNoCSRF) {
+			t.Errorf("%s = %v, want ErrNoCSRF", name, err)
+		}
+	}
+}
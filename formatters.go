@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
+	"net/url"
 	"strings"
 	"time"
 
@@ -19,6 +20,15 @@ import (
 	"golang.org/x/exp/constraints"
 )
 
+// Layouts shared with the Renderer's localized versions of these helpers
+const (
+	layoutMonth         = "Jan"
+	layoutMonthYear     = "January 2006"
+	layoutShortDateTime = "Jan 02 3:04pm"
+	layoutFormal        = "January 02, 2006 at 3:04pm"
+	layoutFullDate      = "Monday, January 2, 2006"
+)
+
 // Location returns New York location
 func location() *time.Location {
 
@@ -37,12 +47,12 @@ func FullDateTimeET(val time.Time) string {
 
 // ShortDateTime returns Eastern Time representation (Jan 01 15:04)
 func ShortDateTime(val time.Time) string {
-	return val.In(location()).Format("Jan 02 3:04pm")
+	return val.In(location()).Format(layoutShortDateTime)
 }
 
 // DateTimeFormal (January 2, 2006 at 3:04PM)
 func DateTimeFormal(val time.Time) string {
-	return val.In(location()).Format("January 02, 2006 at 3:04pm")
+	return val.In(location()).Format(layoutFormal)
 }
 
 // TimeFormat returns AM/PM time format
@@ -57,7 +67,7 @@ func FullDisplayDate(val time.Time) string {
 
 // FullDateFormat ...
 func FullDateFormat(val time.Time, location *time.Location) string {
-	return val.In(location).Format("Monday, January 2, 2006")
+	return val.In(location).Format(layoutFullDate)
 }
 
 // TimeFormatAmPm ...
@@ -130,12 +140,12 @@ func DisplayDateTime(val time.Time) string {
 // DateFormatDisplay (January 2006)
 func DateFormatDisplay(val time.Time) string {
 
-	return val.In(location()).Format("January 2006")
+	return val.In(location()).Format(layoutMonthYear)
 }
 
 // DateMonth (Jan)
 func DateMonth(val time.Time) string {
-	return val.In(location()).Format("Jan")
+	return val.In(location()).Format(layoutMonth)
 }
 
 // DateDay (2)
@@ -189,7 +199,10 @@ func IssueDateFormatDisplay(val time.Time) string {
 	return DateFormatDisplay(val)
 }
 
-// Marshal ...
+// Marshal returns *v* as JSON for use in a <script>, or "" if it
+// cannot be marshaled.
+//
+// Deprecated: use MarshalJS, which reports the error, or JSONScript.
 func Marshal(v interface{}) template.JS {
 	a, _ := json.Marshal(v)
 	return template.JS(a)
@@ -218,15 +231,6 @@ func Format2(d float64) string {
 	return fmt.Sprintf("%.2f", d)
 }
 
-// FormatPhone ...
-func FormatPhone(n string) string {
-	if len(n) == 10 {
-		return "(" + n[0:3] + ") " + n[3:6] + "-" + n[6:10]
-	}
-
-	return n
-}
-
 // Int64Display2FromPrecision10 ...
 func Int64Display2FromPrecision10(number int64) string {
 	//000012357000000 => 1.2347000000
@@ -363,20 +367,30 @@ func ZeroPad64(val int64) string {
 	return fmt.Sprintf("%02d", val)
 }
 
-// FirstInitial returns first initial of name
-func FirstInitial(name string) string {
-	return name[0:1]
-}
-
 // CalcTabIndex ...
 func CalcTabIndex(index int, num int, base int) int {
 	return (index * base) + num
 }
 
-// IsToday ...
+// IsToday reports whether *dte* falls on the current day in New York,
+// according to the package clock (see SetClock).
 func IsToday(dte time.Time) bool {
+	return isTodayAt(DefaultClock(), dte)
+}
+
+// InFuture reports whether *dte* is after the package clock's current time
+func InFuture(dte time.Time, loc *time.Location) bool {
+	return inFutureAt(DefaultClock(), dte, loc)
+}
+
+// InPast reports whether *dte* is before the package clock's current time
+func InPast(dte time.Time, loc *time.Location) bool {
+	return inPastAt(DefaultClock(), dte, loc)
+}
+
+func isTodayAt(c Clock, dte time.Time) bool {
 
-	yearNow, monthNow, dayNow := time.Now().In(location()).Date()
+	yearNow, monthNow, dayNow := c.Now().In(location()).Date()
 	yearDte, monthDte, dayDte := dte.In(location()).Date()
 
 	if yearNow == yearDte && monthNow == monthDte && dayNow == dayDte {
@@ -386,18 +400,20 @@ func IsToday(dte time.Time) bool {
 	return false
 }
 
-func InFuture(dte time.Time, loc *time.Location) bool {
-	now := time.Now().In(loc)
+func inFutureAt(c Clock, dte time.Time, loc *time.Location) bool {
+	now := c.Now().In(loc)
 	return dte.After(now)
 }
 
-func InPast(dte time.Time, loc *time.Location) bool {
-	now := time.Now().In(loc)
+func inPastAt(c Clock, dte time.Time, loc *time.Location) bool {
+	now := c.Now().In(loc)
 	return dte.Before(now)
 }
 
-// NewLineToBR ...
+// NewLineToBR escapes *s* and turns its line breaks into <br>,
+// so user text can be shown with its lines intact.
 func NewLineToBR(s string) template.HTML {
+	s = template.HTMLEscapeString(strings.Replace(s, "\r\n", "\n", -1))
 	return template.HTML(strings.Replace(s, "\n", "<br>", -1))
 }
 
@@ -421,38 +437,11 @@ func DictHelper(values ...interface{}) (map[string]interface{}, error) {
 	return dict, nil
 }
 
-// HTMLEscape ...
+// HTMLEscape sanitizes *input* with HTMLPolicy. It used to return
+// *input* unchanged; templates that really need raw HTML must now
+// say so with unsafeRawHTML.
 func HTMLEscape(input string) template.HTML {
-	return template.HTML(input)
-}
-
-// ToUppercase ...
-func ToUppercase(v string) string {
-	return strings.ToUpper(v)
-}
-
-// ToLowercase ...
-func ToLowercase(v string) string {
-	return strings.ToLower(v)
-}
-
-// ToTitleCase ...
-func ToTitleCase(v string) string {
-	return strings.Title(v)
-}
-
-// PrepPhone replaces ")", "(", "-", " "
-// to make sure it will show up in a "tel"
-// tag with proper format.
-func PrepPhone(v string) string {
-
-	v = strings.Replace(v, "(", "", -1)
-	v = strings.Replace(v, ")", "", -1)
-	v = strings.Replace(v, " ", "", -1)
-	v = strings.Replace(v, "-", "", -1)
-	v = strings.Replace(v, ",", "", -1)
-
-	return v
+	return SanitizeHTML(input)
 }
 
 // Int64ToTime returns an HTML-time-formatted
@@ -468,7 +457,9 @@ func Int64ToTime(v int64) string {
 // Pluralize serves to provide correct plural words when given a count.
 // For example, you may want to print "1 car" or "2 cars" depending on
 // how many cars you have. The function takes the number, then "car" and "cars".
-// It evaluates the number and returns "car" if it is one, otherwise "cars"
+// It evaluates the number and returns "car" if it is one, otherwise "cars".
+// It only knows English; PluralIn (plural in templates) follows the plural
+// rules of other languages.
 func Pluralize[T constraints.Integer](count T, oneItem, otherItems string) string {
 	if count == 1 {
 		return oneItem
@@ -478,12 +469,15 @@ func Pluralize[T constraints.Integer](count T, oneItem, otherItems string) strin
 }
 
 // ArrayToQS takes a key and string array
-// and produces &key=v&key=v, etc
+// and produces &key=v&key=v, etc., with keys and values URL-encoded.
+//
+// Deprecated: use QueryString, URLWith or URLAdd (queryString, urlWith
+// and urlAdd in templates), which build the whole URL.
 func ArrayToQS(key string, values []string) template.URL {
 
 	sb := strings.Builder{}
 	for _, v := range values {
-		sb.WriteString(fmt.Sprintf("&%s=%s", key, v))
+		sb.WriteString("&" + url.QueryEscape(key) + "=" + url.QueryEscape(v))
 	}
 
 	return template.URL(sb.String())
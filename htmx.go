@@ -0,0 +1,138 @@
+package render
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+)
+
+// These helpers are the htmx counterparts of the doGetFetch/doPostFetch
+// JSON contract: HXRedirect for ReportRedirect and RedirectTo, HXRefresh
+// for ReportReload, and Renderer.Partial for ToString fragments. See
+// https://htmx.org/reference/ for the headers.
+
+// IsHTMX reports whether htmx sent *r*
+func IsHTMX(r *http.Request) bool {
+	return r != nil && r.Header.Get("HX-Request") == "true"
+}
+
+// IsBoosted reports whether *r* came from an hx-boost link or form,
+// which swaps the whole body and so expects the full page
+func IsBoosted(r *http.Request) bool {
+	return r != nil && r.Header.Get("HX-Boosted") == "true"
+}
+
+// HXTarget returns the id of the element htmx will swap, if it has one
+func HXTarget(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+
+	return r.Header.Get("HX-Target")
+}
+
+// The response header helpers below only set headers, so they must come
+// before the body is written; the status is left to the caller, e.g.,
+// Render or w.WriteHeader(http.StatusNoContent).
+
+// HXRedirect has htmx navigate to *redirect* with a full page load
+func HXRedirect(w http.ResponseWriter, redirect string) {
+	w.Header().Set("HX-Redirect", redirect)
+}
+
+// HXRefresh has htmx reload the page
+func HXRefresh(w http.ResponseWriter) {
+	w.Header().Set("HX-Refresh", "true")
+}
+
+// HXTrigger has htmx trigger *event* on the target once the response
+// arrives, with *detail* as the event's detail; it may be nil. Calls add
+// to the events already set. It fails only if *detail* can't be marshaled.
+func HXTrigger(w http.ResponseWriter, event string, detail interface{}) error {
+
+	events := make(map[string]json.RawMessage)
+
+	if current := w.Header().Get("HX-Trigger"); current != "" {
+		if err := json.Unmarshal([]byte(current), &events); err != nil {
+			// A plain event name set elsewhere
+			events = map[string]json.RawMessage{current: json.RawMessage("null")}
+		}
+	}
+
+	b, err := json.Marshal(detail)
+	if err != nil {
+		return err
+	}
+	events[event] = b
+
+	header, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("HX-Trigger", string(header))
+	return nil
+}
+
+// HXRetarget has htmx swap the response into *selector* instead of the
+// requesting element's target, e.g., a form's error summary
+func HXRetarget(w http.ResponseWriter, selector string) {
+	w.Header().Set("HX-Retarget", selector)
+}
+
+// HXReswap overrides how htmx swaps the response, e.g., "outerHTML"
+func HXReswap(w http.ResponseWriter, swap string) {
+	w.Header().Set("HX-Reswap", swap)
+}
+
+// OOB wraps *html* for an out-of-band swap into the element with id
+// *id*, for composing with the output of ToHTML or RenderFragment. The
+// wrapper is a div, so it suits targets that may contain one; otherwise
+// put hx-swap-oob on the block's own root element.
+func OOB(id string, html template.HTML) template.HTML {
+	return template.HTML(`<div id="`+template.HTMLEscapeString(id)+`" hx-swap-oob="innerHTML">`) +
+		html + template.HTML(`</div>`)
+}
+
+// Partial sends only the named *blocks* of set *name*, in order, instead
+// of the whole page. The first is swapped into the target; the others
+// are out-of-band swaps and must carry hx-swap-oob on their root element:
+//
+//	{{define "cart-count"}}<span id="cart-count" hx-swap-oob="true">{{.Count}}</span>{{end}}
+//
+//	rd.Partial(w, r, "shop", model, "cart-row", "cart-count")
+//
+// Failures are handled as in Render.
+func (rd *Renderer) Partial(w http.ResponseWriter, r *http.Request, name string, model interface{}, blocks ...string) error {
+	return rd.render(w, r, name, model, blocks...)
+}
+
+// RenderHTMX sends *block* of set *name*, and any out-of-band *oob*
+// blocks, to htmx requests, and the whole page to everything else,
+// including boosted requests. One handler then serves both.
+func (rd *Renderer) RenderHTMX(w http.ResponseWriter, r *http.Request, name, block string, model interface{}, oob ...string) error {
+
+	// Caches must not serve the fragment for the page, or vice versa
+	addVary(w.Header(), "HX-Request")
+
+	if IsHTMX(r) && !IsBoosted(r) {
+		return rd.Partial(w, r, name, model, append([]string{block}, oob...)...)
+	}
+
+	return rd.Render(w, r, name, model)
+}
+
+// RenderBlock sends one {{define}} or {{block}} of the parsed set
+// *setName*, so a page and its fragment updates share one template:
+//
+//	{{define "content"}}...{{block "orders" .}}<table>...</table>{{end}}...{{end}}
+//
+//	rd.RenderBlock(w, "orders", "orders", model)
+//
+// There is no request, so the block renders with the Renderer's clock,
+// the default locale and no CSRF token or URL, so csrfField fails with
+// ErrNoCSRF and currentURL with ErrNoRequest; use Partial when it needs
+// the request. Failures are handled as in Render.
+func (rd *Renderer) RenderBlock(w http.ResponseWriter, setName, blockName string, model interface{}) error {
+	return rd.render(w, nil, setName, model, blockName)
+}
@@ -0,0 +1,114 @@
+package render
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHXTrigger(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		current string
+		event   string
+		detail  interface{}
+		want    string
+	}{
+		{"first", "", "saved", nil, `{"saved":null}`},
+		{"detail", "", "saved", map[string]int{"id": 7}, `{"saved":{"id":7}}`},
+		{"merged", `{"saved":{"id":7}}`, "closeModal", "now", `{"closeModal":"now","saved":{"id":7}}`},
+		{"replaced", `{"saved":{"id":7}}`, "saved", 8, `{"saved":8}`},
+		{"plain name", "refreshList", "saved", true, `{"refreshList":null,"saved":true}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if tt.current != "" {
+				w.Header().Set("HX-Trigger", tt.current)
+			}
+
+			if err := HXTrigger(w, tt.event, tt.detail); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := w.Header().Get("HX-Trigger"); got != tt.want {
+				t.Errorf("HX-Trigger = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if err := HXTrigger(httptest.NewRecorder(), "bad", func() {}); err == nil {
+		t.Error("unmarshalable detail: no error")
+	}
+}
+
+// The header helpers leave the status to the caller
+func TestHXHeadersDoNotWriteStatus(t *testing.T) {
+
+	w := httptest.NewRecorder()
+	HXRedirect(w, "/orders")
+	HXRefresh(w)
+	HXRetarget(w, "#errors")
+	HXReswap(w, "outerHTML")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+
+	for header, want := range map[string]string{
+		"HX-Redirect": "/orders", "HX-Refresh": "true", "HX-Retarget": "#errors", "HX-Reswap": "outerHTML",
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestRenderHTMX(t *testing.T) {
+
+	tmpl := template.Must(template.New("shop").Funcs(GetFuncMap()).Parse(
+		`<main>{{block "cart-row" .}}<tr>{{.}}</tr>{{end}}` +
+			`{{block "cart-count" .}}<span id="cart-count" hx-swap-oob="true">1</span>{{end}}</main>`))
+	rd := newRenderer(t, map[string]*template.Template{"shop": tmpl})
+
+	const (
+		page     = `<main><tr>tea</tr><span id="cart-count" hx-swap-oob="true">1</span></main>`
+		fragment = `<tr>tea</tr><span id="cart-count" hx-swap-oob="true">1</span>`
+	)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{"page", nil, page},
+		{"htmx", map[string]string{"HX-Request": "true"}, fragment},
+		{"boosted", map[string]string{"HX-Request": "true", "HX-Boosted": "true"}, page},
+		{"not htmx", map[string]string{"HX-Request": "false"}, page},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/cart", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+
+			if err := rd.RenderHTMX(w, r, "shop", "cart-row", "tea", "cart-count"); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+			if got := w.Header().Get("Vary"); got != "HX-Request" {
+				t.Errorf("Vary = %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,490 @@
+package render
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// LocalesDir is where LoadCatalog expects message files, relative to the template root
+const LocalesDir = "locales"
+
+// Catalog holds translated UI messages, keyed by the English source text
+// or by an identifier, for use with the t template function:
+//
+//	{{t "Welcome back, %s" .User.FirstName}}
+//	{{t "%d new messages" .Unread}}
+//
+// Messages are printf formats. A message can have plural forms, chosen
+// by the CLDR rules of the language from the first argument.
+type Catalog struct {
+	builder  *catalog.Builder
+	fallback language.Tag
+
+	mu    sync.Mutex // serializes changes with rebuilding the cache
+	cache atomic.Pointer[catalogCache]
+}
+
+// catalogCache holds what Printer derives from the messages; Set and
+// SetPlural drop it
+type catalogCache struct {
+	matcher  language.Matcher
+	printers sync.Map // requested language.Tag -> *message.Printer
+}
+
+// NewCatalog returns an empty catalog; untranslated keys fall back to
+// *fallback*, then to the key itself.
+func NewCatalog(fallback language.Tag) *Catalog {
+	return &Catalog{
+		builder:  catalog.NewBuilder(catalog.Fallback(fallback)),
+		fallback: fallback,
+	}
+}
+
+// LoadCatalog reads every <tag>.json and <tag>.po file in *dir*, e.g.,
+// views/locales/es.json or views/locales/pt-BR.po.
+//
+// A JSON file maps keys to messages; plural messages map CLDR categories
+// ("zero", "one", "two", "few", "many", "other") or exact values ("=0")
+// to messages:
+//
+//	{
+//	  "Welcome back, %s": "Bienvenido, %s",
+//	  "%d new messages": {"=0": "No hay mensajes nuevos", "one": "%d mensaje nuevo", "other": "%d mensajes nuevos"}
+//	}
+//
+// PO files use msgid as the key; msgstr[n] plural forms are assigned to
+// the CLDR categories of the language in order. Fuzzy entries are skipped.
+func LoadCatalog(dir string, fallback language.Tag) (*Catalog, error) {
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewCatalog(fallback)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		ext := filepath.Ext(entry.Name())
+		if ext != ".json" && ext != ".po" {
+			continue
+		}
+
+		tag, err := language.Parse(strings.TrimSuffix(entry.Name(), ext))
+		if err != nil {
+			return nil, fmt.Errorf("%s: file name is not a language tag: %v", entry.Name(), err)
+		}
+
+		path := filepath.Join(dir, entry.Name())
+
+		if ext == ".json" {
+			err = c.loadJSON(tag, path)
+		} else {
+			err = c.loadPO(tag, path)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+
+	return c, nil
+}
+
+// Set adds a message for *key* in *tag*
+func (c *Catalog) Set(tag language.Tag, key, msg string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cache.Store(nil)
+
+	return c.builder.SetString(tag, key, msg)
+}
+
+// SetPlural adds a message with plural *forms*, keyed by CLDR category or
+// "=N". The form is chosen from the first argument passed to Translate.
+func (c *Catalog) SetPlural(tag language.Tag, key string, forms map[string]string) error {
+
+	if _, ok := forms["other"]; !ok {
+		return fmt.Errorf("plural message %q has no \"other\" form", key)
+	}
+
+	// Exact values first, then categories, with "other" last as Selectf requires
+	order := map[string]int{"zero": 1, "one": 2, "two": 3, "few": 4, "many": 5, "other": 6}
+
+	selectors := make([]string, 0, len(forms))
+	for s := range forms {
+		if _, ok := order[s]; !ok && !strings.HasPrefix(s, "=") {
+			return fmt.Errorf("plural message %q: unknown form %q", key, s)
+		}
+		selectors = append(selectors, s)
+	}
+
+	sort.Slice(selectors, func(i, j int) bool {
+		if order[selectors[i]] != order[selectors[j]] {
+			return order[selectors[i]] < order[selectors[j]]
+		}
+		return selectors[i] < selectors[j]
+	})
+
+	cases := make([]interface{}, 0, 2*len(selectors))
+	for _, s := range selectors {
+		cases = append(cases, s, forms[s])
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cache.Store(nil)
+
+	return c.builder.Set(tag, key, plural.Selectf(1, "", cases...))
+}
+
+// Languages returns the languages that have messages
+func (c *Catalog) Languages() []language.Tag {
+	return c.builder.Languages()
+}
+
+// Translate formats the message for *key* in the catalog language closest
+// to *tag*. Missing keys are formatted as given.
+func (c *Catalog) Translate(tag language.Tag, key string, args ...interface{}) string {
+	return c.Printer(tag).Sprintf(key, args...)
+}
+
+// Printer returns a message.Printer for the catalog language closest to
+// *tag*. Printers are shared, and safe for concurrent use.
+func (c *Catalog) Printer(tag language.Tag) *message.Printer {
+
+	cache := c.cache.Load()
+	if cache == nil {
+		cache = c.newCache()
+	}
+
+	if p, ok := cache.printers.Load(tag); ok {
+		return p.(*message.Printer)
+	}
+
+	matched := tag
+	if matched == language.Und {
+		matched = c.fallback
+	}
+
+	matched, _, confidence := cache.matcher.Match(matched)
+	if confidence == language.No {
+		matched = c.fallback
+	}
+
+	p, _ := cache.printers.LoadOrStore(tag, message.NewPrinter(matched, message.Catalog(c.builder)))
+
+	return p.(*message.Printer)
+}
+
+// newCache builds the language matcher, unless another call just did
+func (c *Catalog) newCache() *catalogCache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cache := c.cache.Load(); cache != nil {
+		return cache
+	}
+
+	cache := &catalogCache{matcher: c.builder.Matcher()}
+	c.cache.Store(cache)
+
+	return cache
+}
+
+func (c *Catalog) loadJSON(tag language.Tag, path string) error {
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var messages map[string]json.RawMessage
+	if err := json.Unmarshal(b, &messages); err != nil {
+		return err
+	}
+
+	for key, raw := range messages {
+		var msg string
+		if err := json.Unmarshal(raw, &msg); err == nil {
+			if err := c.Set(tag, key, msg); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var forms map[string]string
+		if err := json.Unmarshal(raw, &forms); err != nil {
+			return fmt.Errorf("message %q must be a string or an object of plural forms", key)
+		}
+
+		if err := c.SetPlural(tag, key, forms); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// poEntry is one msgid/msgstr block of a PO file
+type poEntry struct {
+	id     string
+	plural bool
+	strs   map[int]string
+	fuzzy  bool
+
+	// appendTo receives continuation lines of the keyword last seen
+	appendTo func(string)
+}
+
+func (c *Catalog) loadPO(tag language.Tag, path string) error {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	categories := pluralCategories(tag)
+
+	var (
+		e     *poEntry
+		fuzzy bool
+	)
+
+	flush := func() error {
+		entry := e
+		e = nil
+
+		if entry == nil || entry.fuzzy || entry.id == "" {
+			return nil
+		}
+
+		if !entry.plural {
+			if entry.strs[0] == "" {
+				return nil
+			}
+			return c.Set(tag, entry.id, entry.strs[0])
+		}
+
+		cats := categories
+		if len(entry.strs) != len(cats) {
+			cats = []string{"one", "other"}
+		}
+
+		forms := make(map[string]string)
+		for i, cat := range cats {
+			if s := entry.strs[i]; s != "" {
+				forms[cat] = s
+			}
+		}
+
+		// Languages such as Russian use "other" only for fractions;
+		// let the last form cover them
+		if _, ok := forms["other"]; !ok {
+			last := entry.strs[len(cats)-1]
+			if last == "" {
+				return nil
+			}
+			forms["other"] = last
+		}
+
+		return c.SetPlural(tag, entry.id, forms)
+	}
+
+	// start begins a new entry unless the current one has no msgid yet (after msgctxt)
+	start := func() error {
+		if e != nil && e.id == "" && len(e.strs) == 0 {
+			return nil
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		e = &poEntry{strs: map[int]string{}, fuzzy: fuzzy}
+		fuzzy = false
+		return nil
+	}
+
+	scanner := bufio.NewScanner(f)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+
+		var (
+			keyword string
+			rest    string
+		)
+
+		if i := strings.IndexByte(text, ' '); i > 0 && !strings.HasPrefix(text, "#") {
+			keyword, rest = text[:i], strings.TrimSpace(text[i+1:])
+		}
+
+		switch {
+		case text == "":
+			if err := flush(); err != nil {
+				return err
+			}
+
+		case strings.HasPrefix(text, "#,"):
+			fuzzy = fuzzy || strings.Contains(text, "fuzzy")
+
+		case strings.HasPrefix(text, "#"):
+			// translator and reference comments
+
+		case keyword == "msgctxt":
+			// Contexts are not supported; the entry is keyed by msgid alone
+			if err := start(); err != nil {
+				return err
+			}
+			e.appendTo = func(string) {}
+
+		case keyword == "msgid":
+			if err := start(); err != nil {
+				return err
+			}
+			entry := e
+			entry.appendTo = func(s string) { entry.id += s }
+
+		case keyword == "msgid_plural":
+			if e == nil {
+				return fmt.Errorf("line %d: msgid_plural without msgid", line)
+			}
+			e.plural = true
+			e.appendTo = func(string) {}
+
+		case strings.HasPrefix(keyword, "msgstr"):
+			if e == nil {
+				return fmt.Errorf("line %d: msgstr without msgid", line)
+			}
+
+			index := 0
+			if n := strings.TrimPrefix(keyword, "msgstr"); n != "" {
+				if !strings.HasPrefix(n, "[") || !strings.HasSuffix(n, "]") {
+					return fmt.Errorf("line %d: malformed %s", line, keyword)
+				}
+				if index, err = strconv.Atoi(n[1 : len(n)-1]); err != nil {
+					return fmt.Errorf("line %d: malformed %s", line, keyword)
+				}
+			}
+
+			entry := e
+			entry.strs[index] = ""
+			entry.appendTo = func(s string) { entry.strs[index] += s }
+
+		case strings.HasPrefix(text, `"`):
+			if e == nil || e.appendTo == nil {
+				return fmt.Errorf("line %d: unexpected string", line)
+			}
+			rest = text
+
+		default:
+			return fmt.Errorf("line %d: unexpected %q", line, text)
+		}
+
+		if rest != "" {
+			s, err := strconv.Unquote(rest)
+			if err != nil {
+				return fmt.Errorf("line %d: malformed string %s", line, rest)
+			}
+			e.appendTo(s)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return flush()
+}
+
+// pluralCategories lists the CLDR categories *tag* uses for whole numbers,
+// in the order gettext numbers its plural forms.
+func pluralCategories(tag language.Tag) []string {
+
+	names := []string{"other", "zero", "one", "two", "few", "many"}
+	seen := make(map[plural.Form]bool)
+
+	for i := 0; i <= 1000; i++ {
+		seen[plural.Cardinal.MatchPlural(tag, i, 0, 0, 0, 0)] = true
+	}
+
+	var categories []string
+	for _, form := range []plural.Form{plural.Zero, plural.One, plural.Two, plural.Few, plural.Many, plural.Other} {
+		if seen[form] {
+			categories = append(categories, names[form])
+		}
+	}
+
+	return categories
+}
+
+type localeKey struct{}
+
+// WithLocale returns a copy of *ctx* carrying the locale to render in
+func WithLocale(ctx context.Context, tag language.Tag) context.Context {
+	return context.WithValue(ctx, localeKey{}, tag)
+}
+
+// LocaleFromContext returns the locale stored by WithLocale, or language.Und
+func LocaleFromContext(ctx context.Context) language.Tag {
+	if ctx != nil {
+		if tag, ok := ctx.Value(localeKey{}).(language.Tag); ok {
+			return tag
+		}
+	}
+
+	return language.Und
+}
+
+var (
+	defaultCatalogMu sync.RWMutex
+	defaultCatalog   *Catalog
+)
+
+// SetCatalog sets the catalog used by the t template function when
+// the Renderer has none
+func SetCatalog(c *Catalog) {
+	defaultCatalogMu.Lock()
+	defaultCatalog = c
+	defaultCatalogMu.Unlock()
+}
+
+// DefaultCatalog returns the catalog set by SetCatalog, or nil
+func DefaultCatalog() *Catalog {
+	defaultCatalogMu.RLock()
+	defer defaultCatalogMu.RUnlock()
+
+	return defaultCatalog
+}
+
+// Translate formats *key* in *tag* with the default catalog, or
+// formats *key* as given when no catalog is set.
+func Translate(tag language.Tag, key string, args ...interface{}) string {
+	c := DefaultCatalog()
+	if c == nil {
+		return fmt.Sprintf(key, args...)
+	}
+
+	return c.Translate(tag, key, args...)
+}
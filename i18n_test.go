@@ -0,0 +1,79 @@
+package render
+
+import (
+	"sync"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestCatalogPrinterCache(t *testing.T) {
+
+	c := NewCatalog(language.English)
+	if err := c.Set(language.Spanish, "Hello", "Hola"); err != nil {
+		t.Fatal(err)
+	}
+
+	mx := language.MustParse("es-MX")
+
+	if got := c.Translate(mx, "Hello"); got != "Hola" {
+		t.Errorf("es-MX: got %q", got)
+	}
+	if c.Printer(mx) != c.Printer(mx) {
+		t.Error("Printer made a new printer for the same language")
+	}
+
+	// A language added later is matched once the cache is dropped
+	if got := c.Translate(language.French, "Hello"); got != "Hello" {
+		t.Errorf("fr before Set: got %q", got)
+	}
+	if err := c.Set(language.French, "Hello", "Bonjour"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Translate(language.French, "Hello"); got != "Bonjour" {
+		t.Errorf("fr after Set: got %q", got)
+	}
+
+	if err := c.SetPlural(language.French, "%d files", map[string]string{"one": "%d fichier", "other": "%d fichiers"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Translate(language.French, "%d files", 2); got != "2 fichiers" {
+		t.Errorf("fr plural: got %q", got)
+	}
+
+	if got := c.Translate(language.Und, "Hello"); got != "Hello" {
+		t.Errorf("und: got %q", got)
+	}
+}
+
+func TestCatalogConcurrentUse(t *testing.T) {
+
+	c := NewCatalog(language.English)
+	if err := c.Set(language.Spanish, "Hello", "Hola"); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if got := c.Translate(language.Spanish, "Hello"); got != "Hola" {
+					t.Errorf("got %q", got)
+					return
+				}
+				if i == 0 && j%10 == 0 {
+					if err := c.Set(language.German, "Hello", "Hallo"); err != nil {
+						t.Error(err)
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := c.Translate(language.German, "Hello"); got != "Hallo" {
+		t.Errorf("de: got %q", got)
+	}
+}
@@ -0,0 +1,292 @@
+package render
+
+import (
+	"context"
+	"expvar"
+	"html/template"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RenderInfo describes one finished render
+type RenderInfo struct {
+	Template string
+	Start    time.Time
+	Duration time.Duration
+	Bytes    int   // written to the response, or returned by ToHTML
+	Err      error // from execution or from writing
+}
+
+// Observer is told about every render. StartRender is called before the
+// template executes and returns the context to render with and a func
+// called once with the result, which is the shape of a tracing span:
+//
+//	type tracer struct{ trace.Tracer }
+//
+//	func (t tracer) StartRender(ctx context.Context, name string) (context.Context, func(render.RenderInfo)) {
+//		ctx, span := t.Start(ctx, "render "+name)
+//		return ctx, func(info render.RenderInfo) {
+//			span.SetAttributes(attribute.Int("render.bytes", info.Bytes))
+//			if info.Err != nil {
+//				span.RecordError(info.Err)
+//				span.SetStatus(codes.Error, info.Err.Error())
+//			}
+//			span.End()
+//		}
+//	}
+type Observer interface {
+	StartRender(ctx context.Context, name string) (context.Context, func(RenderInfo))
+}
+
+// ObserverFunc is an Observer that only needs the result
+type ObserverFunc func(ctx context.Context, info RenderInfo)
+
+// StartRender implements Observer
+func (f ObserverFunc) StartRender(ctx context.Context, name string) (context.Context, func(RenderInfo)) {
+	return ctx, func(info RenderInfo) { f(ctx, info) }
+}
+
+// Observers combines several observers into one, e.g., logging and counters
+func Observers(observers ...Observer) Observer {
+	return multiObserver(observers)
+}
+
+type multiObserver []Observer
+
+func (m multiObserver) StartRender(ctx context.Context, name string) (context.Context, func(RenderInfo)) {
+
+	done := make([]func(RenderInfo), 0, len(m))
+
+	for _, o := range m {
+		var finish func(RenderInfo)
+		ctx, finish = o.StartRender(ctx, name)
+		done = append(done, finish)
+	}
+
+	return ctx, func(info RenderInfo) {
+		for i := len(done) - 1; i >= 0; i-- {
+			done[i](info)
+		}
+	}
+}
+
+var (
+	defaultObserverMu sync.RWMutex
+	defaultObserver   Observer
+)
+
+// SetObserver sets the observer used by Template, ToBrowser and Renderers
+// that have none. Passing nil turns observation off.
+func SetObserver(o Observer) {
+	defaultObserverMu.Lock()
+	defaultObserver = o
+	defaultObserverMu.Unlock()
+}
+
+// DefaultObserver returns the observer set by SetObserver, or nil
+func DefaultObserver() Observer {
+	defaultObserverMu.RLock()
+	defer defaultObserverMu.RUnlock()
+
+	return defaultObserver
+}
+
+// observe starts observing a render of *name* with *o*, or with the
+// default observer when *o* is nil. The returned func takes the bytes
+// written and the error.
+func observe(ctx context.Context, o Observer, name string) (context.Context, func(int, error)) {
+
+	if o == nil {
+		o = DefaultObserver()
+	}
+
+	if o == nil {
+		return ctx, func(int, error) {}
+	}
+
+	start := time.Now()
+	ctx, done := o.StartRender(ctx, name)
+
+	return ctx, func(n int, err error) {
+		done(RenderInfo{
+			Template: name,
+			Start:    start,
+			Duration: time.Since(start),
+			Bytes:    n,
+			Err:      err,
+		})
+	}
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
+
+// SlogObserver logs each render: failures at error level, renders
+// slower than SlowThreshold at warn level, the rest at Level.
+type SlogObserver struct {
+	Logger        *slog.Logger // nil means slog.Default()
+	Level         slog.Level   // for successful renders; defaults to debug
+	SlowThreshold time.Duration
+}
+
+// NewSlogObserver logs renders to *logger* at debug level
+func NewSlogObserver(logger *slog.Logger) *SlogObserver {
+	return &SlogObserver{Logger: logger, Level: slog.LevelDebug}
+}
+
+// StartRender implements Observer
+func (o *SlogObserver) StartRender(ctx context.Context, name string) (context.Context, func(RenderInfo)) {
+	return ctx, func(info RenderInfo) {
+
+		logger := o.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+
+		attrs := []slog.Attr{
+			slog.String("template", info.Template),
+			slog.Duration("duration", info.Duration),
+			slog.Int("bytes", info.Bytes),
+		}
+
+		level := o.Level
+		msg := "render"
+
+		switch {
+		case info.Err != nil:
+			level = slog.LevelError
+			msg = "render failed"
+			attrs = append(attrs, slog.Any("error", info.Err))
+		case o.SlowThreshold > 0 && info.Duration >= o.SlowThreshold:
+			level = slog.LevelWarn
+			msg = "slow render"
+		}
+
+		logger.LogAttrs(ctx, level, msg, attrs...)
+	}
+}
+
+// ExpvarObserver counts renders, errors, bytes and time per template in
+// an expvar map, served with the other expvars at /debug/vars:
+//
+//	{"render": {"dashboard": {"renders": 120, "errors": 1, "bytes": 9830400, "nanoseconds": 480000000}}}
+type ExpvarObserver struct {
+	vars *expvar.Map
+}
+
+// NewExpvarObserver publishes the counters under *name*, e.g., "render".
+// Observers created with the same name share the counters.
+func NewExpvarObserver(name string) *ExpvarObserver {
+
+	if v, ok := expvar.Get(name).(*expvar.Map); ok {
+		return &ExpvarObserver{vars: v}
+	}
+
+	return &ExpvarObserver{vars: expvar.NewMap(name)}
+}
+
+// StartRender implements Observer
+func (o *ExpvarObserver) StartRender(ctx context.Context, name string) (context.Context, func(RenderInfo)) {
+	return ctx, func(info RenderInfo) {
+
+		counters, ok := o.vars.Get(info.Template).(*expvar.Map)
+		if !ok {
+			counters = new(expvar.Map).Init()
+			o.vars.Set(info.Template, counters)
+		}
+
+		counters.Add("renders", 1)
+		counters.Add("bytes", int64(info.Bytes))
+		counters.Add("nanoseconds", int64(info.Duration))
+		if info.Err != nil {
+			counters.Add("errors", 1)
+		}
+	}
+}
+
+// RecentRenders remembers the last renders and lists them on a page, for
+// development. The page is only served in dev mode (see SetDevMode).
+//
+//	recent := render.NewRecentRenders(100)
+//	renderer.Observer = render.Observers(logging, recent)
+//	http.Handle("/_render/recent", recent)
+type RecentRenders struct {
+	mu      sync.Mutex
+	renders []RenderInfo
+	next    int
+	full    bool
+}
+
+// NewRecentRenders keeps the last *n* renders
+func NewRecentRenders(n int) *RecentRenders {
+	if n < 1 {
+		n = 1
+	}
+
+	return &RecentRenders{renders: make([]RenderInfo, n)}
+}
+
+// StartRender implements Observer
+func (rr *RecentRenders) StartRender(ctx context.Context, name string) (context.Context, func(RenderInfo)) {
+	return ctx, func(info RenderInfo) {
+		rr.mu.Lock()
+		defer rr.mu.Unlock()
+
+		rr.renders[rr.next] = info
+		rr.next = (rr.next + 1) % len(rr.renders)
+		rr.full = rr.full || rr.next == 0
+	}
+}
+
+// Renders returns the remembered renders, newest first
+func (rr *RecentRenders) Renders() []RenderInfo {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	count := rr.next
+	if rr.full {
+		count = len(rr.renders)
+	}
+
+	out := make([]RenderInfo, 0, count)
+	for i := 1; i <= count; i++ {
+		out = append(out, rr.renders[(rr.next-i+len(rr.renders))%len(rr.renders)])
+	}
+
+	return out
+}
+
+var recentRendersPage = template.Must(template.New("recent").Parse(`<!DOCTYPE html>
+<html><head><title>Recent renders</title>
+<style>body{font-family:sans-serif}td,th{padding:2px 8px;text-align:left}td.n{text-align:right}tr.err{color:#b00}</style>
+</head><body><h1>Recent renders</h1>
+<table><tr><th>Time</th><th>Template</th><th>Duration</th><th>Bytes</th><th>Error</th></tr>
+{{range .}}<tr{{if .Err}} class="err"{{end}}><td>{{.Start.Format "15:04:05.000"}}</td><td>{{.Template}}</td><td class="n">{{.Duration}}</td><td class="n">{{.Bytes}}</td><td>{{with .Err}}{{.Error}}{{end}}</td></tr>
+{{else}}<tr><td colspan="5">No renders yet</td></tr>{{end}}
+</table></body></html>`))
+
+// ServeHTTP lists the recent renders, or responds 404 outside dev mode
+func (rr *RecentRenders) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if !DevMode() {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	recentRendersPage.Execute(w, rr.Renders())
+}
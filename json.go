@@ -0,0 +1,383 @@
+package render
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// FieldTag is the struct tag listing the groups a field is published to,
+// e.g., `render:"page,admin"`. MarshalJSFor and JSONScriptFor only emit
+// tagged fields, so anything server-only stays out of the page.
+const FieldTag = "render"
+
+var devMode atomic.Bool
+
+// SetDevMode turns development conveniences on or off, such as
+// indented JSON in pages.
+func SetDevMode(on bool) {
+	devMode.Store(on)
+}
+
+// DevMode reports whether SetDevMode(true) was called
+func DevMode() bool {
+	return devMode.Load()
+}
+
+// encodeJSON marshals *v*, indented in dev mode. json.Marshal escapes
+// <, > and &, so the result is safe inside <script> elements.
+func encodeJSON(v interface{}) ([]byte, error) {
+	if DevMode() {
+		return json.MarshalIndent(v, "", "  ")
+	}
+
+	return json.Marshal(v)
+}
+
+// MarshalJS marshals *v* for use inside a <script>, returning the
+// marshaling error so the template stops instead of emitting broken JavaScript.
+func MarshalJS(v interface{}) (template.JS, error) {
+	b, err := encodeJSON(v)
+	if err != nil {
+		return template.JS(""), err
+	}
+
+	return template.JS(b), nil
+}
+
+// MarshalJSFor is MarshalJS limited to fields tagged with *group*
+//
+//	type Student struct {
+//		Name string `json:"name" render:"page"`
+//		SSN  string `json:"ssn"` // never emitted
+//	}
+func MarshalJSFor(group string, v interface{}) (template.JS, error) {
+	filtered, err := FilterFields(group, v)
+	if err != nil {
+		return template.JS(""), err
+	}
+
+	return MarshalJS(filtered)
+}
+
+// JSONScriptFor is JSONScript limited to fields tagged with *group*
+func JSONScriptFor(id, group string, v interface{}) (template.HTML, error) {
+	filtered, err := FilterFields(group, v)
+	if err != nil {
+		return template.HTML(""), err
+	}
+
+	return JSONScript(id, filtered)
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// FilterFields copies *v* into maps and slices that keep only the struct
+// fields whose render tag lists *group*, at every depth. Field names, and
+// the fields promoted from embedded structs, follow encoding/json; a tag on
+// an embedded struct covers all its fields. Values that marshal
+// themselves, such as time.Time, are kept whole.
+func FilterFields(group string, v interface{}) (interface{}, error) {
+	return filterValue(group, reflect.ValueOf(v), 0)
+}
+
+func filterValue(group string, v reflect.Value, depth int) (interface{}, error) {
+
+	if depth > 64 {
+		return nil, fmt.Errorf("FilterFields: value nested too deeply (cycle?)")
+	}
+
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	// A struct embedded unexported under a json name can't be read
+	// whole, but its exported fields can
+	readable := v.CanInterface()
+	if !readable && v.Kind() != reflect.Ptr && v.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	if readable && (v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType)) {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return nil, nil
+		}
+		return v.Interface(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return filterValue(group, v.Elem(), depth+1)
+
+	case reflect.Struct:
+		out := make(map[string]interface{})
+		if err := filterStruct(group, v, out, depth); err != nil {
+			return nil, err
+		}
+		return out, nil
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface(), nil // []byte marshals as base64
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			item, err := filterValue(group, v.Index(i), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = item
+		}
+		return out, nil
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			item, err := filterValue(group, iter.Value(), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			out[fmt.Sprint(iter.Key().Interface())] = item
+		}
+		return out, nil
+	}
+
+	return v.Interface(), nil
+}
+
+func filterStruct(group string, v reflect.Value, out map[string]interface{}, depth int) error {
+
+	for _, field := range structFields(v.Type()) {
+		if !inGroup(field.groups, group) {
+			continue
+		}
+
+		// Read through the outer value, so fields promoted from
+		// unexported embedded structs stay readable
+		value, err := v.FieldByIndexErr(field.index)
+		if err != nil {
+			continue // promoted through a nil embedded pointer
+		}
+
+		if field.omitEmpty && value.IsZero() {
+			continue
+		}
+
+		item, err := filterValue(group, value, depth+1)
+		if err != nil {
+			return err
+		}
+
+		out[field.name] = item
+	}
+
+	return nil
+}
+
+// structField is a field encoding/json emits for a struct type
+type structField struct {
+	name      string
+	index     []int // for FieldByIndex, through embedded structs
+	tagged    bool  // named by a json tag
+	omitEmpty bool
+	groups    string // render tags of the field and the embedded fields it is promoted through
+}
+
+var structFieldCache sync.Map // reflect.Type -> []structField
+
+// structFields returns the fields encoding/json emits for *t*, applying
+// its rules for embedded structs: untagged embedded structs, exported or
+// not, contribute their fields; of fields sharing a name the shallowest
+// wins, then the json-tagged one, and a name still ambiguous is dropped.
+// Tagging an embedded struct with a group publishes all of its fields.
+func structFields(t reflect.Type) []structField {
+
+	if cached, ok := structFieldCache.Load(t); ok {
+		return cached.([]structField)
+	}
+
+	type embedded struct {
+		typ    reflect.Type
+		index  []int
+		groups string
+	}
+
+	var (
+		fields  []structField
+		next    = []embedded{{typ: t}}
+		visited = map[reflect.Type]bool{}
+	)
+
+	for len(next) > 0 {
+		current := next
+		next = nil
+
+		// Fields found at this depth, by name
+		level := make(map[string][]structField)
+		var names []string
+
+		// A struct embedded twice at one depth makes its fields ambiguous
+		count := make(map[reflect.Type]int)
+		for _, e := range current {
+			count[e.typ]++
+		}
+
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+
+				name, omitEmpty, skip := jsonName(sf)
+				if skip {
+					continue
+				}
+
+				index := append(append([]int(nil), e.index...), i)
+				groups := e.groups + "," + sf.Tag.Get(FieldTag)
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+
+				if sf.Anonymous {
+					if strings.Split(sf.Tag.Get("json"), ",")[0] == "" && ft.Kind() == reflect.Struct {
+						next = append(next, embedded{typ: ft, index: index, groups: groups})
+						continue
+					}
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+
+				if _, ok := level[name]; !ok {
+					names = append(names, name)
+				}
+				f := structField{
+					name:      name,
+					index:     index,
+					tagged:    strings.Split(sf.Tag.Get("json"), ",")[0] != "",
+					omitEmpty: omitEmpty,
+					groups:    groups,
+				}
+				level[name] = append(level[name], f)
+				if count[e.typ] > 1 {
+					level[name] = append(level[name], f)
+				}
+			}
+		}
+
+		for _, name := range names {
+			if hidden(fields, name) {
+				continue // a shallower field has the name
+			}
+			if f, ok := dominant(level[name]); ok {
+				fields = append(fields, f)
+			} else {
+				fields = append(fields, structField{name: name}) // ambiguous: hides deeper fields, emits nothing
+			}
+		}
+	}
+
+	// Drop the ambiguous placeholders
+	out := fields[:0]
+	for _, f := range fields {
+		if f.index != nil {
+			out = append(out, f)
+		}
+	}
+
+	structFieldCache.Store(t, out)
+
+	return out
+}
+
+func hidden(fields []structField, name string) bool {
+	for _, f := range fields {
+		if f.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dominant picks the field encoding/json emits among *fields* of one name
+// at one depth: the only one, or the only one with a json tag
+func dominant(fields []structField) (structField, bool) {
+
+	if len(fields) == 1 {
+		return fields[0], true
+	}
+
+	var tagged []structField
+	for _, f := range fields {
+		if f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+
+	return structField{}, false
+}
+
+// jsonName returns the key encoding/json would use for *field*
+func jsonName(field reflect.StructField) (name string, omitEmpty, skip bool) {
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+
+	return name, omitEmpty, false
+}
+
+func inGroup(tag, group string) bool {
+	for _, g := range strings.Split(tag, ",") {
+		if strings.TrimSpace(g) == group {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,168 @@
+package render
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type audit struct {
+	CreatedBy string    `json:"createdBy" render:"page"`
+	Created   time.Time `json:"created" render:"page"`
+}
+
+type address struct {
+	City string `json:"city" render:"page"`
+}
+
+type contact struct {
+	Email string `json:"email" render:"page"`
+	Name  string `json:"name" render:"page"`
+}
+
+type note struct {
+	Name string `render:"page"`
+}
+
+type label struct {
+	Name string `render:"page"`
+}
+
+type tagged struct {
+	Name string `json:"name" render:"page"`
+}
+
+type order struct {
+	audit                 // unexported, promoted
+	*address              // unexported pointer, promoted
+	contact               // Name is hidden by the outer field
+	Number   string       `json:"number" render:"page"`
+	Name     string       `json:"name" render:"page"`
+	Secret   string       `json:"secret"`
+	Items    []orderItem  `json:"items" render:"page"`
+	Meta     *orderDetail `json:"meta,omitempty" render:"page"`
+}
+
+type orderItem struct {
+	SKU  string `json:"sku" render:"page"`
+	Cost int    `json:"cost"`
+}
+
+type orderDetail struct {
+	Note string `render:"page"`
+}
+
+// Two untagged Names at one depth cancel out; a tagged one wins
+type ambiguous struct {
+	note
+	label
+}
+
+type tagWins struct {
+	note
+	tagged
+}
+
+// A struct embedded with a json name is an object, not promoted fields
+type named struct {
+	contact `json:"contact" render:"page"`
+}
+
+// A group tag on an embedded struct publishes all its fields
+type wholeEmbedded struct {
+	orderItem `render:"page"`
+}
+
+// FilterFields matches encoding/json when every field is in the group
+func TestFilterFieldsMatchesJSON(t *testing.T) {
+
+	when := time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		v    interface{}
+		want string // only for values with fields outside the group
+	}{
+		{"promoted", order{
+			audit:   audit{CreatedBy: "ann", Created: when},
+			address: &address{City: "Austin"},
+			contact: contact{Email: "a@example.com", Name: "hidden"},
+			Number:  "A-1",
+			Name:    "Ann",
+			Secret:  "s",
+			Items:   []orderItem{{SKU: "X", Cost: 3}},
+		}, `{"createdBy":"ann","created":"2024-01-15T09:30:00Z","city":"Austin","email":"a@example.com",` +
+			`"number":"A-1","name":"Ann","items":[{"sku":"X"}]}`},
+		{"nil embedded pointer", &order{Number: "A-2", Meta: &orderDetail{Note: "n"}},
+			`{"createdBy":"","created":"0001-01-01T00:00:00Z","email":"","number":"A-2","name":"","items":null,"meta":{"Note":"n"}}`},
+		{"ambiguous", ambiguous{note{"a"}, label{"b"}}, ""},
+		{"tag wins", tagWins{note{"a"}, tagged{"b"}}, ""},
+		{"named embedded", named{contact{Email: "e", Name: "n"}}, ""},
+		{"tagged embedded", wholeEmbedded{orderItem{SKU: "X", Cost: 3}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered, err := FilterFields("page", tt.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := json.Marshal(filtered)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := []byte(tt.want)
+			if tt.want == "" {
+				if want, err = json.Marshal(tt.v); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if !sameJSON(t, got, want) {
+				t.Errorf("got  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+func sameJSON(t *testing.T, a, b []byte) bool {
+	t.Helper()
+
+	var x, y interface{}
+	if err := json.Unmarshal(a, &x); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &y); err != nil {
+		t.Fatal(err)
+	}
+
+	return reflect.DeepEqual(x, y)
+}
+
+func TestFilterFieldsGroups(t *testing.T) {
+
+	type row struct {
+		ID    int    `json:"id" render:"page,admin"`
+		Email string `json:"email" render:"admin"`
+		Hash  string `json:"-" render:"page"`
+	}
+
+	for group, want := range map[string]string{
+		"page":  `{"id":1}`,
+		"admin": `{"id":1,"email":"e"}`,
+		"other": `{}`,
+	} {
+		filtered, err := FilterFields(group, row{ID: 1, Email: "e", Hash: "h"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, _ := json.Marshal(filtered)
+		if !sameJSON(t, got, []byte(want)) {
+			t.Errorf("%s: got %s, want %s", group, got, want)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package render
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goodsign/monday"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// Locales negotiates the locale of each request against the languages
+// the site supports. An explicit choice wins: the query parameter (so a
+// language switcher can link to ?lang=es), then the cookie, then the
+// Accept-Language header, then the first supported tag.
+//
+//	renderer.Locales = &render.Locales{
+//		Supported: []language.Tag{language.English, language.Spanish, language.French},
+//	}
+type Locales struct {
+	// Supported lists the site's languages; the first is the default
+	Supported []language.Tag
+
+	QueryParam string // defaults to "lang"; "-" disables
+	CookieName string // defaults to "lang"; "-" disables
+
+	once    sync.Once
+	matcher language.Matcher
+}
+
+// Negotiate returns the supported locale that best fits *r*
+func (l *Locales) Negotiate(r *http.Request) language.Tag {
+
+	if len(l.Supported) == 0 {
+		return language.Und
+	}
+
+	l.once.Do(func() {
+		l.matcher = language.NewMatcher(l.Supported)
+	})
+
+	var preferred []string
+
+	if name := defaultString(l.QueryParam, "lang"); name != "-" && r.URL != nil {
+		if v := r.URL.Query().Get(name); v != "" {
+			preferred = append(preferred, v)
+		}
+	}
+
+	if name := defaultString(l.CookieName, "lang"); name != "-" {
+		if c, err := r.Cookie(name); err == nil && c.Value != "" {
+			preferred = append(preferred, c.Value)
+		}
+	}
+
+	preferred = append(preferred, r.Header.Get("Accept-Language"))
+
+	// MatchStrings skips values that don't parse, so a bad cookie or
+	// parameter falls through to the header
+	_, index := language.MatchStrings(l.matcher, preferred...)
+
+	return l.Supported[index]
+}
+
+// Middleware stores the negotiated locale in each request's context,
+// for handlers that need it before rendering, and marks the response
+// as varying with it
+func (l *Locales) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if LocaleFromContext(r.Context()) == language.Und {
+			l.vary(w.Header())
+			r = r.WithContext(WithLocale(r.Context(), l.Negotiate(r)))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// vary adds the request headers Negotiate reads to the Vary header, so
+// caches keep a copy of the response per language. The query parameter
+// is part of the URL, so it needs none.
+func (l *Locales) vary(h http.Header) {
+	addVary(h, "Accept-Language")
+
+	if defaultString(l.CookieName, "lang") != "-" {
+		addVary(h, "Cookie")
+	}
+}
+
+func defaultString(v, def string) string {
+	if v == "" {
+		return def
+	}
+
+	return v
+}
+
+// Localizer formats text, dates and numbers for one locale. The Renderer
+// exposes the request's as {{locale}}; {{inLocale "fr"}} returns another,
+// to render a block in a different language:
+//
+//	<html lang="{{locale}}">
+//	{{with inLocale "fr"}}<p>{{.T "Welcome"}} {{.Date $.When "2 January 2006"}}</p>{{end}}
+//
+// Only the methods called on the returned Localizer, such as .T, .Date,
+// .Number and .Plural, use its language. The functions inside the block,
+// e.g., t, localeDate or intDisplay0, keep the request's locale.
+type Localizer struct {
+	Tag     language.Tag
+	catalog *Catalog
+}
+
+// NewLocalizer returns a Localizer for *tag* translating with *catalog*,
+// or with DefaultCatalog when *catalog* is nil
+func NewLocalizer(tag language.Tag, catalog *Catalog) *Localizer {
+	return &Localizer{Tag: tag, catalog: catalog}
+}
+
+// String returns the BCP 47 tag, e.g., "es-MX", for lang attributes
+func (l *Localizer) String() string {
+	if l.Tag == language.Und {
+		return "en"
+	}
+
+	return l.Tag.String()
+}
+
+// T translates *key* (see Catalog)
+func (l *Localizer) T(key string, args ...interface{}) string {
+	c := l.catalog
+	if c == nil {
+		c = DefaultCatalog()
+	}
+
+	if c == nil {
+		return fmt.Sprintf(key, args...)
+	}
+
+	return c.Translate(l.Tag, key, args...)
+}
+
+// Date formats *val* in New York time with Go's reference *layout*,
+// with month and day names in the locale's language
+func (l *Localizer) Date(val time.Time, layout string) string {
+	if val.IsZero() {
+		return ""
+	}
+
+	return monday.Format(val.In(location()), layout, mondayLocale(l.Tag))
+}
+
+// formatTime formats *val* with Go's reference *layout*, with month and
+// day names in the locale's language
+func (l *Localizer) formatTime(val time.Time, layout string) string {
+	if l.Tag == language.Und {
+		return val.Format(layout)
+	}
+
+	return monday.Format(val, layout, mondayLocale(l.Tag))
+}
+
+// Number formats *v* with *decimals* digits after the decimal point
+// and the locale's separators, e.g., 1,234.50 or 1.234,50
+func (l *Localizer) Number(v interface{}, decimals int) string {
+	return l.printer().Sprint(number.Decimal(v, number.Scale(decimals)))
+}
+
+// localizeDigits converts *s*, a number grouped with commas and with a
+// decimal point, e.g., by utils.FormatCommas, to the locale's
+// separators. Unlike Number, it keeps every digit of large values.
+func (l *Localizer) localizeDigits(s string) string {
+	if l.Tag == language.Und {
+		return s
+	}
+
+	sep, ok := separators.Load(l.Tag)
+	if !ok {
+		sep, _ = separators.LoadOrStore(l.Tag, l.separators())
+	}
+
+	return sep.(*strings.Replacer).Replace(s)
+}
+
+// separators returns a replacer from English separators to the
+// locale's, read off a formatted sample, e.g., 1.234.567,5 or 1 234 567,5
+func (l *Localizer) separators() *strings.Replacer {
+
+	sample := l.printer().Sprint(number.Decimal(1234567.5, number.Scale(1)))
+
+	i, j := strings.Index(sample, "234"), strings.Index(sample, "567")
+	if !strings.HasPrefix(sample, "1") || i < 1 || j < i+3 || !strings.HasSuffix(sample, "5") {
+		// Digits other than 0-9
+		return strings.NewReplacer()
+	}
+
+	group, decimal := sample[1:i], sample[j+3:len(sample)-1]
+
+	return strings.NewReplacer(",", group, ".", decimal)
+}
+
+var separators sync.Map // language.Tag -> *strings.Replacer
+
+// Sprintf formats like fmt.Sprintf, with numbers in the locale's style
+func (l *Localizer) Sprintf(format string, args ...interface{}) string {
+	return l.printer().Sprintf(format, args...)
+}
+
+func (l *Localizer) printer() *message.Printer {
+	tag := l.Tag
+	if tag == language.Und {
+		tag = language.English
+	}
+
+	return message.NewPrinter(tag)
+}
+
+// InLocale returns a Localizer for *lang*, a BCP 47 tag such as "fr" or "pt-BR"
+func InLocale(lang string) (*Localizer, error) {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLocalizer(tag, nil), nil
+}
+
+// LocaleDate formats *val* like Localizer.Date, in English. Rendered through
+// a Renderer, localeDate uses the request's locale.
+func LocaleDate(val time.Time, layout string) string {
+	return NewLocalizer(language.Und, nil).Date(val, layout)
+}
+
+// LocaleNumber formats *v* like Localizer.Number, in English. Rendered
+// through a Renderer, localeNumber uses the request's locale.
+func LocaleNumber(v interface{}, decimals int) string {
+	return NewLocalizer(language.Und, nil).Number(v, decimals)
+}
+
+var (
+	mondayLocalesOnce sync.Once
+	mondayLocales     map[string]monday.Locale
+)
+
+// mondayLocale maps *tag* to the closest locale monday knows,
+// e.g., es -> es_ES, pt-BR -> pt_BR, falling back to en_US.
+func mondayLocale(tag language.Tag) monday.Locale {
+
+	mondayLocalesOnce.Do(func() {
+		mondayLocales = make(map[string]monday.Locale)
+		for _, l := range monday.ListLocales() {
+			mondayLocales[strings.ToLower(string(l))] = l
+
+			// The first locale listed for a language is its default
+			base := strings.ToLower(strings.SplitN(string(l), "_", 2)[0])
+			if _, ok := mondayLocales[base]; !ok {
+				mondayLocales[base] = l
+			}
+		}
+	})
+
+	base, _ := tag.Base()
+	region, _ := tag.Region()
+
+	if l, ok := mondayLocales[strings.ToLower(base.String()+"_"+region.String())]; ok {
+		return l
+	}
+
+	if l, ok := mondayLocales[strings.ToLower(base.String())]; ok {
+		return l
+	}
+
+	return monday.LocaleEnUS
+}
@@ -0,0 +1,131 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// The error policy of this package: helpers that write responses return
+// their errors to the caller and never put internal error text in the
+// response. Failures the package handles itself, such as a failed
+// Renderer.Render, are logged with the request's attributes to the
+// Renderer's Logger, or to the package logger set by SetLogger.
+
+var (
+	defaultLoggerMu sync.RWMutex
+	defaultLogger   *slog.Logger
+)
+
+// SetLogger sets the logger used when a Renderer has none, and by the
+// package-level helpers. Passing nil restores slog.Default().
+func SetLogger(l *slog.Logger) {
+	defaultLoggerMu.Lock()
+	defaultLogger = l
+	defaultLoggerMu.Unlock()
+}
+
+// DefaultLogger returns the logger set by SetLogger, or slog.Default()
+func DefaultLogger() *slog.Logger {
+	defaultLoggerMu.RLock()
+	defer defaultLoggerMu.RUnlock()
+
+	if defaultLogger == nil {
+		return slog.Default()
+	}
+
+	return defaultLogger
+}
+
+// RequestAttrs returns the attributes that identify *r* in logs: method,
+// path and, when a proxy or App Engine set one, the request or trace id
+func RequestAttrs(r *http.Request) []slog.Attr {
+
+	if r == nil {
+		return nil
+	}
+
+	attrs := []slog.Attr{slog.String("method", r.Method)}
+
+	if r.URL != nil {
+		attrs = append(attrs, slog.String("path", r.URL.Path))
+	}
+
+	if id := r.Header.Get("X-Request-Id"); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+
+	// App Engine: TRACE_ID/SPAN_ID;o=1
+	if trace := r.Header.Get("X-Cloud-Trace-Context"); trace != "" {
+		attrs = append(attrs, slog.String("trace", strings.SplitN(trace, "/", 2)[0]))
+	}
+
+	return attrs
+}
+
+// logError logs *err* with *msg*, the attributes of *r* and *attrs*
+func logError(logger *slog.Logger, r *http.Request, msg string, err error, attrs ...slog.Attr) {
+
+	if logger == nil {
+		logger = DefaultLogger()
+	}
+
+	ctx := context.Background()
+	if r != nil {
+		ctx = r.Context()
+	}
+
+	attrs = append(RequestAttrs(r), append(attrs, slog.Any("error", err))...)
+
+	logger.LogAttrs(ctx, slog.LevelError, msg, attrs...)
+}
+
+// RenderError is returned by Renderer.Render and Renderer.ToHTML when a
+// template fails, after the failure has been logged
+type RenderError struct {
+	Template string
+	Err      error
+}
+
+func (e *RenderError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the execution or write error
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
+
+// ServerError logs *err* with the attributes of *r*, unless it is a
+// RenderError that was logged already, and responds with a plain 500
+// that reveals nothing about it
+func ServerError(w http.ResponseWriter, r *http.Request, err error) {
+	serverError(nil, w, r, err)
+}
+
+// logger returns the Renderer's Logger, or DefaultLogger
+func (rd *Renderer) logger() *slog.Logger {
+	if rd.Logger != nil {
+		return rd.Logger
+	}
+
+	return DefaultLogger()
+}
+
+// ServerError is ServerError logging to the Renderer's Logger
+func (rd *Renderer) ServerError(w http.ResponseWriter, r *http.Request, err error) {
+	serverError(rd.logger(), w, r, err)
+}
+
+func serverError(logger *slog.Logger, w http.ResponseWriter, r *http.Request, err error) {
+
+	var rendered *RenderError
+	if !errors.As(err, &rendered) {
+		logError(logger, r, "internal error", err)
+	}
+
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
@@ -0,0 +1,93 @@
+package render
+
+import (
+	"bytes"
+	"html/template"
+	"sync"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// MarkdownOptions configures Markdown rendering
+type MarkdownOptions struct {
+	// LinkRel is set on every link; empty means "nofollow noopener"
+	LinkRel string
+
+	// LinkTarget is set on every link when not empty, e.g., "_blank"
+	LinkTarget string
+
+	// HardWraps turns single newlines into <br>, as users typing
+	// into a notes field usually expect
+	HardWraps bool
+}
+
+// markdownRenderer pairs a configured goldmark instance with its sanitizer
+type markdownRenderer struct {
+	md     goldmark.Markdown
+	policy *Policy
+}
+
+var (
+	markdownMu      sync.RWMutex
+	defaultMarkdown = newMarkdownRenderer(MarkdownOptions{HardWraps: true})
+)
+
+func newMarkdownRenderer(opts MarkdownOptions) *markdownRenderer {
+
+	rendererOptions := []renderer.Option{}
+	if opts.HardWraps {
+		rendererOptions = append(rendererOptions, goldmarkhtml.WithHardWraps())
+	}
+
+	policy := MarkdownPolicy()
+	if opts.LinkRel != "" {
+		policy.LinkRel = opts.LinkRel
+	}
+	policy.LinkTarget = opts.LinkTarget
+
+	return &markdownRenderer{
+		md: goldmark.New(
+			goldmark.WithExtensions(extension.Table, extension.Strikethrough, extension.Linkify),
+			goldmark.WithRendererOptions(rendererOptions...),
+		),
+		policy: policy,
+	}
+}
+
+func (m *markdownRenderer) render(source string) (template.HTML, error) {
+	var b bytes.Buffer
+
+	if err := m.md.Convert([]byte(source), &b); err != nil {
+		return template.HTML(""), err
+	}
+
+	return m.policy.SanitizeHTML(b.String()), nil
+}
+
+// SetMarkdownOptions changes the options used by Markdown and the markdown template function
+func SetMarkdownOptions(opts MarkdownOptions) {
+	m := newMarkdownRenderer(opts)
+
+	markdownMu.Lock()
+	defaultMarkdown = m
+	markdownMu.Unlock()
+}
+
+// Markdown renders CommonMark *source*, with tables, autolinks and
+// strikethrough, into sanitized HTML. Raw HTML in the source is not
+// rendered, and the output passes through MarkdownPolicy.
+func Markdown(source string) (template.HTML, error) {
+	markdownMu.RLock()
+	m := defaultMarkdown
+	markdownMu.RUnlock()
+
+	return m.render(source)
+}
+
+// MarkdownWith renders *source* like Markdown, using *opts* instead of the package options
+func MarkdownWith(source string, opts MarkdownOptions) (template.HTML, error) {
+	return newMarkdownRenderer(opts).render(source)
+}
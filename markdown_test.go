@@ -0,0 +1,66 @@
+package render
+
+import (
+	"testing"
+)
+
+func TestMarkdownSanitized(t *testing.T) {
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		// Raw HTML
+		{"script", "hi <script>alert(1)</script> there", "<p>hi alert(1) there</p>\n"},
+		{"html block", `<div onclick="x()">raw</div>`, "\n"},
+		{"img onerror", `<img src=x onerror=alert(1)>`, "\n"},
+		{"inline element", `text <b onclick="x">inline</b> raw`, "<p>text inline raw</p>\n"},
+		{"raw link", `<a href="javascript:alert(1)">raw link</a> text`, "<p>raw link text</p>\n"},
+
+		// javascript: and other unsafe schemes
+		{"javascript link", "[click](javascript:alert(1))", `<p><a href="" rel="nofollow noopener">click</a></p>` + "\n"},
+		{"mixed case scheme", "[click](JaVaScRiPt:alert(1))", `<p><a href="" rel="nofollow noopener">click</a></p>` + "\n"},
+		{"javascript autolink", "<javascript:alert(1)>", `<p><a href="" rel="nofollow noopener">javascript:alert(1)</a></p>` + "\n"},
+		{"tab in scheme", "[a](<java\tscript:alert(1)>)", "<p><a>a</a></p>\n"},
+		{"vbscript autolink", "<vbscript:msgbox(1)>", `<p><a href="" rel="nofollow noopener">vbscript:msgbox(1)</a></p>` + "\n"},
+		{"javascript image", "![pic](javascript:alert(1))", `<p><img src="" alt="pic" /></p>` + "\n"},
+
+		// data: URLs, including the images goldmark itself lets through
+		{"data html autolink", "<data:text/html;base64,PHNjcmlwdD4=>", `<p><a href="" rel="nofollow noopener">data:text/html;base64,PHNjcmlwdD4=</a></p>` + "\n"},
+		{"data image autolink", "<data:image/png;base64,iVBORw0KGgo=>", "<p><a>data:image/png;base64,iVBORw0KGgo=</a></p>\n"},
+		{"data image", "![x](data:image/png;base64,iVBORw0KGgo=)", `<p><img alt="x" /></p>` + "\n"},
+		{"data text is not linkified", "see data:text/html;base64,PHNjcmlwdD4= now", "<p>see data:text/html;base64,PHNjcmlwdD4= now</p>\n"},
+
+		// What the policy keeps
+		{"linkified url", "visit https://example.com/a?b=1 now",
+			`<p>visit <a href="https://example.com/a?b=1" rel="nofollow noopener">https://example.com/a?b=1</a> now</p>` + "\n"},
+		{"titled link", `[ok](https://example.com "t")`, `<p><a href="https://example.com" title="t" rel="nofollow noopener">ok</a></p>` + "\n"},
+		{"formatting", "**bold** and ~~gone~~\nline", "<p><strong>bold</strong> and <del>gone</del><br />\nline</p>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Markdown(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownWithLinkOptions(t *testing.T) {
+
+	got, err := MarkdownWith("[a](https://x.test) [b](javascript:x)", MarkdownOptions{LinkRel: "ugc", LinkTarget: "_blank"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<p><a href="https://x.test" rel="ugc noopener" target="_blank">a</a> <a href="" rel="ugc noopener" target="_blank">b</a></p>` + "\n"
+	if string(got) != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
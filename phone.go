@@ -0,0 +1,118 @@
+package render
+
+import (
+	"html/template"
+	"strings"
+	"sync"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+var (
+	phoneRegionMu sync.RWMutex
+	phoneRegion   = "US"
+)
+
+// SetPhoneRegion sets the ISO 3166 region (e.g., "US", "GB") used to
+// interpret numbers written without a leading +country code.
+func SetPhoneRegion(region string) {
+	phoneRegionMu.Lock()
+	phoneRegion = strings.ToUpper(region)
+	phoneRegionMu.Unlock()
+}
+
+// PhoneRegion returns the default region set by SetPhoneRegion
+func PhoneRegion() string {
+	phoneRegionMu.RLock()
+	defer phoneRegionMu.RUnlock()
+
+	return phoneRegion
+}
+
+// ParsePhone parses *raw* as written by a user, e.g., "(202) 555-0100 x12",
+// "+44 20 7946 0958" or "1-202-555-0100". Numbers without a country code are read
+// in *region*; an empty *region* uses PhoneRegion().
+func ParsePhone(raw, region string) (*phonenumbers.PhoneNumber, error) {
+	if region == "" {
+		region = PhoneRegion()
+	}
+
+	return phonenumbers.Parse(raw, region)
+}
+
+// NormalizePhone returns *raw* in E.164 form (+12025550100) with any
+// extension dropped, for storage and comparison.
+func NormalizePhone(raw, region string) (string, error) {
+	num, err := ParsePhone(raw, region)
+	if err != nil {
+		return "", err
+	}
+
+	return phonenumbers.Format(num, phonenumbers.E164), nil
+}
+
+// FormatPhone formats a phone number for display: national format for
+// numbers in the default region, e.g., "(202) 555-0100 ext. 12", and
+// international format for others, e.g., "+44 20 7946 0958".
+// Input that cannot be parsed is returned unchanged.
+func FormatPhone(n string) string {
+	num, err := ParsePhone(n, "")
+	if err != nil {
+		return n
+	}
+
+	if phonenumbers.GetRegionCodeForNumber(num) == PhoneRegion() {
+		return phonenumbers.Format(num, phonenumbers.NATIONAL)
+	}
+
+	return phonenumbers.Format(num, phonenumbers.INTERNATIONAL)
+}
+
+// FormatPhoneNational formats *n* as dialed inside its own country, e.g., "020 7946 0958"
+func FormatPhoneNational(n string) string {
+	num, err := ParsePhone(n, "")
+	if err != nil {
+		return n
+	}
+
+	return phonenumbers.Format(num, phonenumbers.NATIONAL)
+}
+
+// FormatPhoneInternational formats *n* with its country code, e.g., "+1 202-555-0100"
+func FormatPhoneInternational(n string) string {
+	num, err := ParsePhone(n, "")
+	if err != nil {
+		return n
+	}
+
+	return phonenumbers.Format(num, phonenumbers.INTERNATIONAL)
+}
+
+// PrepPhone returns the number part of a "tel" link: E.164 plus
+// ";ext=" when the number has an extension, e.g., "+12025550100;ext=12".
+// Input that cannot be parsed falls back to stripping
+// punctuation and spaces.
+func PrepPhone(v string) string {
+	num, err := ParsePhone(v, "")
+	if err != nil {
+		return strings.NewReplacer("(", "", ")", "", " ", "", "-", "", ",", "", ".", "").Replace(v)
+	}
+
+	e164 := phonenumbers.Format(num, phonenumbers.E164)
+	if ext := num.GetExtension(); ext != "" {
+		e164 += ";ext=" + ext
+	}
+
+	return e164
+}
+
+// TelURI returns a complete RFC 3966 "tel:" URI for *v*,
+// e.g., tel:+1-202-555-0100;ext=12. Input that cannot be parsed yields "".
+func TelURI(v string) template.URL {
+	num, err := ParsePhone(v, "")
+	if err != nil {
+		return template.URL("")
+	}
+
+	return template.URL(phonenumbers.Format(num, phonenumbers.RFC3966))
+}
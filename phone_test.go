@@ -0,0 +1,94 @@
+package render
+
+import (
+	"testing"
+)
+
+func TestParsePhone(t *testing.T) {
+
+	tests := []struct {
+		raw, region string
+		country     int32
+		national    uint64
+		ext         string
+		ok          bool
+	}{
+		{"(202) 555-0100", "", 1, 2025550100, "", true},
+		{"1-202-555-0100", "", 1, 2025550100, "", true},
+		{"(202) 555-0100 x12", "", 1, 2025550100, "12", true},
+		{"202.555.0100 ext. 12", "US", 1, 2025550100, "12", true},
+		{"+44 20 7946 0958", "", 44, 2079460958, "", true},
+		{"020 7946 0958", "GB", 44, 2079460958, "", true},
+		{"", "", 0, 0, "", false},
+		{"call me", "", 0, 0, "", false},
+		{"020 7946 0958", "XX", 0, 0, "", false},
+	}
+
+	for _, tt := range tests {
+		num, err := ParsePhone(tt.raw, tt.region)
+		if (err == nil) != tt.ok {
+			t.Errorf("%q in %q: err = %v", tt.raw, tt.region, err)
+			continue
+		}
+		if !tt.ok {
+			continue
+		}
+
+		if num.GetCountryCode() != tt.country || num.GetNationalNumber() != tt.national || num.GetExtension() != tt.ext {
+			t.Errorf("%q in %q: got +%d %d ext %q", tt.raw, tt.region, num.GetCountryCode(), num.GetNationalNumber(), num.GetExtension())
+		}
+	}
+}
+
+func TestPhoneFormats(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		in      string
+		display string
+		prep    string
+		tel     string
+	}{
+		{"home region", "2025550100", "(202) 555-0100", "+12025550100", "tel:+1-202-555-0100"},
+		{"home region with country code", "+1 202 555 0100", "(202) 555-0100", "+12025550100", "tel:+1-202-555-0100"},
+		{"extension", "(202) 555-0100 x12", "(202) 555-0100 ext. 12", "+12025550100;ext=12", "tel:+1-202-555-0100;ext=12"},
+		{"foreign", "+44 20 7946 0958", "+44 20 7946 0958", "+442079460958", "tel:+44-20-7946-0958"},
+		{"foreign extension", "+44 20 7946 0958 ext 7", "+44 20 7946 0958 x7", "+442079460958;ext=7", "tel:+44-20-7946-0958;ext=7"},
+		{"invalid", "call (me)", "call (me)", "callme", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatPhone(tt.in); got != tt.display {
+				t.Errorf("FormatPhone = %q, want %q", got, tt.display)
+			}
+			if got := PrepPhone(tt.in); got != tt.prep {
+				t.Errorf("PrepPhone = %q, want %q", got, tt.prep)
+			}
+			if got := string(TelURI(tt.in)); got != tt.tel {
+				t.Errorf("TelURI = %q, want %q", got, tt.tel)
+			}
+		})
+	}
+}
+
+// The home region decides which numbers get the national format
+func TestFormatPhoneRegion(t *testing.T) {
+
+	SetPhoneRegion("gb")
+	defer SetPhoneRegion("US")
+
+	if got := PhoneRegion(); got != "GB" {
+		t.Errorf("PhoneRegion = %q", got)
+	}
+
+	for in, want := range map[string]string{
+		"020 7946 0958":    "020 7946 0958",
+		"+44 20 7946 0958": "020 7946 0958",
+		"+1 202 555 0100":  "+1 202-555-0100",
+	} {
+		if got := FormatPhone(in); got != want {
+			t.Errorf("FormatPhone(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"golang.org/x/exp/constraints"
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+var pluralFormNames = map[plural.Form]string{
+	plural.Zero:  "zero",
+	plural.One:   "one",
+	plural.Two:   "two",
+	plural.Few:   "few",
+	plural.Many:  "many",
+	plural.Other: "other",
+}
+
+// PluralCategory returns the CLDR plural category of *count* in *tag*:
+// "zero", "one", "two", "few", "many" or "other". For example, 21 is
+// "one" in Russian and 0 is "zero" in Arabic.
+func PluralCategory[T constraints.Integer](tag language.Tag, count T) string {
+	return pluralFormNames[plural.Cardinal.MatchPlural(pluralTag(tag), pluralInt(count), 0, 0, 0, 0)]
+}
+
+// PluralIn picks the text for *count* from *forms*, given as pairs of
+// category and text. "=N" matches an exact count and is checked first;
+// "other" is required and used when nothing else matches.
+//
+//	render.PluralIn(language.English, n, "=0", "no cars", "one", "car", "other", "cars")
+func PluralIn[T constraints.Integer](tag language.Tag, count T, forms ...string) (string, error) {
+
+	if len(forms)%2 != 0 {
+		return "", fmt.Errorf("plural forms must be category/text pairs")
+	}
+
+	byCategory := make(map[string]string, len(forms)/2)
+	for i := 0; i < len(forms); i += 2 {
+		byCategory[forms[i]] = forms[i+1]
+	}
+
+	other, ok := byCategory["other"]
+	if !ok {
+		return "", fmt.Errorf("plural forms have no \"other\" form")
+	}
+
+	if text, ok := byCategory["="+strconv.FormatInt(int64(count), 10)]; ok {
+		return text, nil
+	}
+
+	if text, ok := byCategory[PluralCategory(tag, count)]; ok {
+		return text, nil
+	}
+
+	return other, nil
+}
+
+// ordinalSuffixes maps a language to the suffix for each CLDR ordinal
+// category. Languages that mark ordinals the same way for every number
+// have only "other".
+var ordinalSuffixes = map[string]map[string]string{
+	"en": {"one": "st", "two": "nd", "few": "rd", "other": "th"},
+	"fr": {"one": "er", "other": "e"},
+	"es": {"other": ".º"},
+	"pt": {"other": "º"},
+	"it": {"other": "º"},
+	"de": {"other": "."},
+	"da": {"other": "."},
+	"nb": {"other": "."},
+	"fi": {"other": "."},
+	"nl": {"other": "e"},
+	"sv": {"one": ":a", "other": ":e"},
+}
+
+// Ordinal renders *n* as an ordinal in *tag*, e.g., 1st, 2nd, 3rd, 11th
+// and 22nd in English, 1er and 2e in French, 3.º in Spanish. Languages
+// without a known form get the bare number.
+func Ordinal[T constraints.Integer](tag language.Tag, n T) string {
+
+	tag = pluralTag(tag)
+	base, _ := tag.Base()
+
+	digits := strconv.FormatInt(int64(n), 10)
+	if uint64(n) > math.MaxInt64 && n > 0 {
+		digits = strconv.FormatUint(uint64(n), 10)
+	}
+
+	suffixes, ok := ordinalSuffixes[base.String()]
+	if !ok {
+		return digits
+	}
+
+	category := pluralFormNames[plural.Ordinal.MatchPlural(tag, pluralInt(n), 0, 0, 0, 0)]
+
+	suffix, ok := suffixes[category]
+	if !ok {
+		suffix = suffixes["other"]
+	}
+
+	return digits + suffix
+}
+
+// pluralTag treats an unknown locale as English, like the other helpers
+func pluralTag(tag language.Tag) language.Tag {
+	if tag == language.Und {
+		return language.English
+	}
+
+	return tag
+}
+
+// pluralInt converts *n* to the non-negative int the plural rules
+// expect. Beyond a billion only the last nine digits matter to any rule
+// except "exact millions", so larger values keep those and stay large.
+func pluralInt[T constraints.Integer](n T) int {
+
+	var u uint64
+	if n < 0 {
+		u = uint64(-int64(n))
+	} else {
+		u = uint64(n)
+	}
+
+	if u >= 1e9 {
+		u = u%1e9 + 1e9
+	}
+
+	return int(u)
+}
+
+// PluralCategory returns the CLDR plural category of *count* in the Localizer's language
+func (l *Localizer) PluralCategory(count int) string {
+	return PluralCategory(l.Tag, count)
+}
+
+// Plural picks the text for *count* in the Localizer's language (see PluralIn)
+func (l *Localizer) Plural(count int, forms ...string) (string, error) {
+	return PluralIn(l.Tag, count, forms...)
+}
+
+// PluralInt64 is Plural for int64 counts
+func (l *Localizer) PluralInt64(count int64, forms ...string) (string, error) {
+	return PluralIn(l.Tag, count, forms...)
+}
+
+// Ordinal renders *n* as an ordinal in the Localizer's language
+func (l *Localizer) Ordinal(n int) string {
+	return Ordinal(l.Tag, n)
+}
+
+// OrdinalInt64 is Ordinal for int64 values
+func (l *Localizer) OrdinalInt64(n int64) string {
+	return Ordinal(l.Tag, n)
+}
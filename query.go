@@ -0,0 +1,168 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+)
+
+// ErrNoRequest is returned by currentURL when the page is not rendered
+// by a Renderer for a request, e.g., by Template or RenderBlock, since
+// links built on an empty URL would point at the wrong page
+var ErrNoRequest = errors.New("no request; currentURL needs Renderer.Render, Partial or ToHTML")
+
+// QueryString builds an encoded query string, "?a=1&b=x+y", from
+// key/value pairs. A value may be a string, a number, a []string
+// (repeated key) or nil (key omitted).
+//
+//	<a href="/search{{queryString "q" .Query "tag" .Tags}}">
+func QueryString(pairs ...interface{}) (template.URL, error) {
+
+	values := url.Values{}
+	if err := applyPairs(values, pairs, false); err != nil {
+		return template.URL(""), err
+	}
+
+	if len(values) == 0 {
+		return template.URL(""), nil
+	}
+
+	return template.URL("?" + values.Encode()), nil
+}
+
+// URLWith returns *base* with each key in *pairs* set to its value,
+// replacing existing values; a nil value removes the key. *base* is a
+// *url.URL (e.g., currentURL) or a string.
+//
+//	<a href="{{urlWith currentURL "page" (plusOne .Page)}}">Next</a>
+//	<a href="{{urlWith currentURL "sort" "name" "page" nil}}">Name</a>
+func URLWith(base interface{}, pairs ...interface{}) (template.URL, error) {
+	return editURL(base, func(values url.Values) error {
+		return applyPairs(values, pairs, true)
+	})
+}
+
+// URLAdd returns *base* with each value in *pairs* appended to its key,
+// keeping existing values, e.g., to add one more filter.
+func URLAdd(base interface{}, pairs ...interface{}) (template.URL, error) {
+	return editURL(base, func(values url.Values) error {
+		return applyPairs(values, pairs, false)
+	})
+}
+
+// URLWithout returns *base* without the given keys
+func URLWithout(base interface{}, keys ...string) (template.URL, error) {
+	return editURL(base, func(values url.Values) error {
+		for _, key := range keys {
+			values.Del(key)
+		}
+		return nil
+	})
+}
+
+// URLWithoutValue returns *base* with one value of a repeated key
+// removed, e.g., to drop a single filter from ?tag=a&tag=b.
+func URLWithoutValue(base interface{}, key string, value interface{}) (template.URL, error) {
+	return editURL(base, func(values url.Values) error {
+		remove := fmt.Sprint(value)
+
+		kept := values[key][:0]
+		for _, v := range values[key] {
+			if v != remove {
+				kept = append(kept, v)
+			}
+		}
+
+		if len(kept) == 0 {
+			values.Del(key)
+		} else {
+			values[key] = kept
+		}
+
+		return nil
+	})
+}
+
+// editURL parses *base*, lets *edit* change its query and re-encodes it.
+// Only relative, http and https URLs are accepted, because the result is
+// trusted by html/template.
+func editURL(base interface{}, edit func(url.Values) error) (template.URL, error) {
+
+	var u url.URL
+
+	switch b := base.(type) {
+	case *url.URL:
+		if b != nil {
+			u = *b
+		}
+	case url.URL:
+		u = b
+	case string:
+		parsed, err := url.Parse(b)
+		if err != nil {
+			return template.URL(""), err
+		}
+		u = *parsed
+	case template.URL:
+		parsed, err := url.Parse(string(b))
+		if err != nil {
+			return template.URL(""), err
+		}
+		u = *parsed
+	default:
+		return template.URL(""), fmt.Errorf("unsupported base URL type %T", base)
+	}
+
+	if scheme := strings.ToLower(u.Scheme); scheme != "" && scheme != "http" && scheme != "https" {
+		return template.URL(""), fmt.Errorf("unsafe URL scheme %q", u.Scheme)
+	}
+
+	values := u.Query()
+	if err := edit(values); err != nil {
+		return template.URL(""), err
+	}
+
+	u.RawQuery = values.Encode()
+	u.ForceQuery = false
+
+	return template.URL(u.String()), nil
+}
+
+// applyPairs adds each key and value in *pairs* to *values*, first
+// dropping existing values of the key when *replace* is set.
+// A nil value always deletes the key.
+func applyPairs(values url.Values, pairs []interface{}, replace bool) error {
+
+	if len(pairs)%2 != 0 {
+		return errors.New("query parameters must be key/value pairs")
+	}
+
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return fmt.Errorf("query parameter keys must be strings, got %T", pairs[i])
+		}
+
+		if replace || pairs[i+1] == nil {
+			values.Del(key)
+		}
+
+		switch v := pairs[i+1].(type) {
+		case nil:
+		case []string:
+			for _, s := range v {
+				values.Add(key, s)
+			}
+		case []interface{}:
+			for _, s := range v {
+				values.Add(key, fmt.Sprint(s))
+			}
+		default:
+			values.Add(key, fmt.Sprint(v))
+		}
+	}
+
+	return nil
+}
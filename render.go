@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"html/template"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
@@ -15,44 +16,52 @@ import (
 
 	"github.com/bjbigler/utils"
 	"golang.org/x/net/context"
+	"golang.org/x/text/language"
 )
 
 // ParseTemplateSets parses sets of files into templates, one per page needed.
 // The *baseTemplate* should be a relative path, e.g., "views/master.html"
 // In *sets*, the first string should be the template map lookup name.
 // Ex: {"authenticators", "views/master.html", "views/authenticators.html"}
+// The base template is parsed once and cloned for each set.
 func ParseTemplateSets(baseTemplate string, sets [][]string) (templates map[string]*template.Template, err error) {
 
 	templates = make(map[string]*template.Template)
 
-	funcMap := GetFuncMap()
-
 	templateName := baseTemplate
 	templateParts := strings.Split(baseTemplate, "/")
 	if len(templateParts) > 0 {
 		templateName = templateParts[len(templateParts)-1]
 	}
 
-	for _, set := range sets {
+	base := template.New(templateName).Funcs(GetFuncMap())
 
-		t := template.New(templateName).Funcs(funcMap)
+	if err = parseFiles(base, os.ReadFile, baseTemplate); err != nil {
+		return nil, err
+	}
 
-		templateSet := []string{baseTemplate}
-		templateSet = append(templateSet, set[1:]...)
+	for _, set := range sets {
 
-		_, err = t.ParseFiles(templateSet...)
+		if len(set) == 0 {
+			continue // Skip empty sets
+		}
 
+		t, err := base.Clone()
 		if err != nil {
 			return nil, err
 		}
 
+		if err = parseFiles(t, os.ReadFile, set[1:]...); err != nil {
+			return nil, err
+		}
+
 		//put the template in map using the lookup name
 		lookupName := set[0]
 		templates[lookupName] = t
 
 	}
 
-	return templates, err
+	return templates, nil
 }
 
 // ParseTemplates parses sets of files into templates, one per page needed,
@@ -61,11 +70,26 @@ func ParseTemplateSets(baseTemplate string, sets [][]string) (templates map[stri
 // In a change to ParseTemplateSets, more than one base template can be added.
 // In *sets*, the first string should be the template map lookup name.
 // Ex: {"authenticators", "views/master.html", "views/authenticators.html"}
+// The base templates are parsed once and cloned for each set; see Bundle
+// to also skip reading them from disk.
 func ParseTemplates(sets [][]string, baseTemplates ...string) (templates map[string]*template.Template, err error) {
+	return parseTemplates(os.ReadFile, sets, baseTemplates)
+}
+
+func parseTemplates(read func(string) ([]byte, error), sets [][]string, baseTemplates []string) (templates map[string]*template.Template, err error) {
 
 	templates = make(map[string]*template.Template, len(sets))
 
-	funcMap := GetFuncMap()
+	// The first file is the set's root, which Execute runs, as with
+	// template.ParseFiles
+	var base *template.Template
+	if len(baseTemplates) > 0 {
+		base = template.New(filepath.Base(baseTemplates[0])).Funcs(GetFuncMap())
+
+		if err = parseFiles(base, read, baseTemplates...); err != nil {
+			return nil, err
+		}
+	}
 
 	for _, set := range sets {
 
@@ -73,36 +97,69 @@ func ParseTemplates(sets [][]string, baseTemplates ...string) (templates map[str
 			continue // Skip empty sets
 		}
 
-		templateName := utils.GenerateRandomAlphaNumeric(10)
+		var t *template.Template
+		if base != nil {
+			if t, err = base.Clone(); err != nil {
+				return nil, err
+			}
+		} else if len(set) > 1 {
+			t = template.New(filepath.Base(set[1])).Funcs(GetFuncMap())
+		} else {
+			return nil, fmt.Errorf("template set %s has no files", set[0])
+		}
 
-		t := template.New(templateName).Funcs(funcMap)
+		if err = parseFiles(t, read, set[1:]...); err != nil {
+			return nil, err
+		}
 
+		//put the template in map using the lookup name
 		lookupName := set[0]
-		templateFiles := append(baseTemplates, set[1:]...)
+		templates[lookupName] = t
 
-		_, err = t.ParseFiles(templateFiles...)
+	}
+
+	return templates, nil
+}
 
+// parseFiles parses *files* into *t* the way template.ParseFiles does,
+// naming each by its base name, with *read* supplying the contents
+func parseFiles(t *template.Template, read func(string) ([]byte, error), files ...string) error {
+
+	for _, file := range files {
+		b, err := read(file)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		//put the template in map using the lookup name
-
-		templates[lookupName] = t
+		tmpl := t
+		if name := filepath.Base(file); name != t.Name() {
+			tmpl = t.New(name)
+		}
 
+		if _, err := tmpl.Parse(string(b)); err != nil {
+			return err
+		}
 	}
 
-	return templates, nil
+	return nil
 }
 
 // Template renders template from the template map produced by ParseTemplateSets
 func Template(w http.ResponseWriter, templates map[string]*template.Template, model interface{}, templateIndex string) error {
 
+	_, done := observe(context.Background(), nil, templateIndex)
+
 	template, ok := templates[templateIndex]
 	if ok {
-		return template.Execute(w, &model)
+		cw := &countingWriter{w: w}
+		err := template.Execute(cw, &model)
+		done(cw.n, err)
+		return err
 	}
-	return fmt.Errorf("template %s not found", templateIndex)
+
+	err := fmt.Errorf("template %s not found", templateIndex)
+	done(0, err)
+	return err
 
 }
 
@@ -145,15 +202,22 @@ func ToBrowser(w http.ResponseWriter, model interface{}, templates ...string) er
 
 	templates = append([]string{"views/master.html"}, templates...)
 
-	funcMap := GetFuncMap()                       //Sets up the funcMaps to be used on templates, e.g., formatters like displayDate
-	tmpl := template.New("master.html")           //Initializes named template
-	funcs := tmpl.Funcs(funcMap)                  //associates the funcMap with the template
-	parsed, err := funcs.ParseFiles(templates...) //Parses the templates
-	t := template.Must(parsed, err)               //Creates template
-	err = t.Execute(w, model)                     //Merges template with data
+	name := strings.Join(templates[1:], ",")
+
+	_, done := observe(context.Background(), nil, name)
+	cw := &countingWriter{w: w}
+
+	funcMap := GetFuncMap()                  //Sets up the funcMaps to be used on templates, e.g., formatters like displayDate
+	tmpl := template.New("master.html")      //Initializes named template
+	funcs := tmpl.Funcs(funcMap)             //associates the funcMap with the template
+	t, err := funcs.ParseFiles(templates...) //Parses the templates
+	if err == nil {
+		err = t.Execute(cw, model) //Merges template with data
+	}
+	done(cw.n, err)
 
 	if err != nil {
-		fmt.Println(err)
+		logError(nil, nil, "render failed", err, slog.String("template", name))
 		return err
 	}
 
@@ -161,9 +225,9 @@ func ToBrowser(w http.ResponseWriter, model interface{}, templates ...string) er
 }
 
 // RedirectTo ...
-func RedirectTo(w http.ResponseWriter, redirectURL *url.URL) {
+func RedirectTo(w http.ResponseWriter, redirectURL *url.URL) error {
 	jsonOut := fmt.Sprintf(`{"statusCode": %v, "redirectTo":"%s" }`, 6, redirectURL.String())
-	JSONToBrowser(w, []byte(jsonOut))
+	return JSONToBrowser(w, []byte(jsonOut))
 }
 
 // ToBrowserNoMaster prints out template with no master.
@@ -174,7 +238,10 @@ func ToBrowserNoMaster(w http.ResponseWriter, instanceTemplate string, model int
 	//Here, we grab the name by grabbing the text after the final slash
 	positionOfLastSlash := strings.LastIndex(instanceTemplate, "/")
 	templateName := string(instanceTemplate[positionOfLastSlash+1:])
-	t := template.Must(template.New(templateName).Funcs(funcMap).ParseFiles(instanceTemplate))
+	t, err := template.New(templateName).Funcs(funcMap).ParseFiles(instanceTemplate)
+	if err != nil {
+		return err
+	}
 
 	if err := t.Execute(w, model); err != nil {
 		return err
@@ -194,13 +261,18 @@ func ToBrowserNoMasterNew(w http.ResponseWriter, model interface{}, templates ..
 	//Here, we grab the name by grabbing the text after the final slash
 	positionOfLastSlash := strings.LastIndex(templates[0], "/")
 	templateName := string(templates[0][positionOfLastSlash+1:])
-	t := template.Must(template.New(templateName).Funcs(funcMap).ParseFiles(templates...))
-
-	if err := t.Execute(w, model); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	t, err := template.New(templateName).Funcs(funcMap).ParseFiles(templates...)
+	if err == nil {
+		var b bytes.Buffer
+		if err = t.Execute(&b, model); err == nil {
+			return htmlToBrowser(w, b.Bytes())
+		}
 	}
 
-	return nil
+	logError(nil, nil, "render failed", err, slog.String("template", templateName))
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+	return err
 }
 
 // ToString renders the instanceTemplate alone, without any master supplied.
@@ -217,10 +289,12 @@ func ToString(model interface{}, templates ...string) (string, error) {
 	positionOfLastSlash := strings.LastIndex(instanceTemplate, "/")
 	templateName := string(instanceTemplate[positionOfLastSlash+1:])
 
-	tmpl := template.New(templateName)            //Initializes named template
-	funcs := tmpl.Funcs(funcMap)                  //associates the funcMap with the template
-	parsed, err := funcs.ParseFiles(templates...) //Parses the templates
-	t := template.Must(parsed, err)               //Creates template
+	tmpl := template.New(templateName)       //Initializes named template
+	funcs := tmpl.Funcs(funcMap)             //associates the funcMap with the template
+	t, err := funcs.ParseFiles(templates...) //Parses the templates
+	if err != nil {
+		return "", err
+	}
 
 	var doc bytes.Buffer
 
@@ -381,57 +455,70 @@ func BytesToBrowser(w http.ResponseWriter, html []byte) error {
 	return err
 }
 
+// htmlToBrowser sends a rendered page. Unlike BytesToBrowser it sets no
+// CORS headers, so pages aren't readable from other origins.
+func htmlToBrowser(w http.ResponseWriter, html []byte) error {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err := w.Write(html)
+
+	return err
+}
+
 // CsvToBrowser takes a [][]string and sends it to the browser as a CSV file.
-func CsvToBrowser(w http.ResponseWriter, csvRecords [][]string, filename string) {
+// Nothing is sent if the records can't be encoded.
+func CsvToBrowser(w http.ResponseWriter, csvRecords [][]string, filename string) error {
+
+	b := &bytes.Buffer{}
+	csvWriter := csv.NewWriter(b)
+	if err := csvWriter.WriteAll(csvRecords); err != nil {
+		return err
+	}
+
 	w.Header().Set("Content-Type", "text/csv")
 	w.Header().Set("Content-Disposition", "attachment;filename="+filename)
 	w.Header().Add("Access-Control-Allow-Credentials", "true")
 
-	b := &bytes.Buffer{}
-	csvWriter := csv.NewWriter(b)
-	csvWriter.WriteAll(csvRecords)
+	_, err := w.Write(b.Bytes())
 
-	w.Write(b.Bytes())
+	return err
 }
 
 // WriteXlsToBrowser takes a string formatted as Office XML and outputs it to the browswer as a file.
-func WriteXlsToBrowser(ctx context.Context, w http.ResponseWriter, xls string, filename string) {
+func WriteXlsToBrowser(ctx context.Context, w http.ResponseWriter, xls string, filename string) error {
 	w.Header().Set("Content-Type", "application/vnd.ms-excel")
 	w.Header().Set("Content-Disposition", "attachment;filename="+filename)
 	w.Header().Add("Access-Control-Allow-Credentials", "true")
 
 	b := bytes.NewBuffer([]byte(xls))
 
-	if _, err := b.WriteTo(w); err != nil {
-		output := fmt.Sprintf("%v", err)
-		fmt.Fprint(w, output)
-	}
+	_, err := b.WriteTo(w)
+
+	return err
 }
 
-func XlsxToBrowser(ctx context.Context, w http.ResponseWriter, filename string, file *bytes.Buffer) {
+// XlsxToBrowser sends an .xlsx workbook to the browser as a download
+func XlsxToBrowser(ctx context.Context, w http.ResponseWriter, filename string, file *bytes.Buffer) error {
 	w.Header().Set("Content-Type", "application/octect-stream")
 	w.Header().Set("Content-Disposition", "attachment;filename="+filename)
 	w.Header().Set("Content-Transfer-Encoding", "binary")
 	w.Header().Add("Access-Control-Allow-Credentials", "true")
 
-	if _, err := file.WriteTo(w); err != nil {
-		output := fmt.Sprintf("%v", err)
-		fmt.Fprint(w, output)
-	}
+	_, err := file.WriteTo(w)
+
+	return err
 }
 
 // WriteIcsToBrowser delivers iCalendar files to the browser
-func WriteIcsToBrowser(w http.ResponseWriter, calendar string, filename string) {
+func WriteIcsToBrowser(w http.ResponseWriter, calendar string, filename string) error {
 	w.Header().Set("Content-Type", "text/calendar")
 	w.Header().Set("Content-Disposition", "attachment;filename="+filename)
 	w.Header().Add("Access-Control-Allow-Credentials", "true")
 
 	b := bytes.NewBuffer([]byte(calendar))
 
-	if _, err := b.WriteTo(w); err != nil {
-		output := fmt.Sprintf("%v", err)
-		fmt.Fprint(w, output)
-	}
+	_, err := b.WriteTo(w)
+
+	return err
 }
 
 // PDFToBrowser streams PDF file to browser. Its main purpose
@@ -507,7 +594,7 @@ func ContentTypeToBrowser(w http.ResponseWriter, file []byte, fileName, contentT
 }
 
 // ReportError sends JSON message with "statusCode:0" and "error:" with the error specified
-func ReportError(w http.ResponseWriter, message interface{}) {
+func ReportError(w http.ResponseWriter, message interface{}) error {
 	type helper struct {
 		StatusCode int    `json:"statusCode"`
 		Error      string `json:"error"`
@@ -518,12 +605,16 @@ func ReportError(w http.ResponseWriter, message interface{}) {
 		Error:      fmt.Sprintf("%v", message),
 	}
 
-	jsonOut, _ := json.Marshal(h)
-	JSONToBrowser(w, jsonOut)
+	jsonOut, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+
+	return JSONToBrowser(w, jsonOut)
 }
 
 // ReportMessage sends JSON messagae with "statusCode:0" and "msg:" *message
-func ReportMessage(w http.ResponseWriter, message string) {
+func ReportMessage(w http.ResponseWriter, message string) error {
 
 	type helper struct {
 		StatusCode int    `json:"statusCode"`
@@ -535,15 +626,18 @@ func ReportMessage(w http.ResponseWriter, message string) {
 		Message:    message,
 	}
 
-	jsonOut, _ := json.Marshal(h)
-	JSONToBrowser(w, jsonOut)
+	jsonOut, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
 
+	return JSONToBrowser(w, jsonOut)
 }
 
 // ReportRedirect sends JSON message with "statusCode" of 1 "redirect" equal to the *redirect*
 // provided. If  *redirectID* is also provided, the javascript will
 // attempt to scroll into view any found element with that ID.
-func ReportRedirect(w http.ResponseWriter, redirect string) {
+func ReportRedirect(w http.ResponseWriter, redirect string) error {
 	type helper struct {
 		StatusCode int    `json:"statusCode"`
 		Redirect   string `json:"redirect"`
@@ -554,21 +648,29 @@ func ReportRedirect(w http.ResponseWriter, redirect string) {
 		Redirect:   redirect,
 	}
 
-	jsonOut, _ := json.Marshal(h)
-	JSONToBrowser(w, jsonOut)
+	jsonOut, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+
+	return JSONToBrowser(w, jsonOut)
 }
 
 // ReportErrors sends JSON message with "statusCode:0" and the errors specified
-func ReportErrors(w http.ResponseWriter, errors []error) {
-	errorsJSON, _ := json.Marshal(errors)
+func ReportErrors(w http.ResponseWriter, errors []error) error {
+	errorsJSON, err := json.Marshal(errors)
+	if err != nil {
+		return err
+	}
+
 	jsonOut := fmt.Sprintf(`{"statusCode": %v, "errors":%v }`, 0, string(errorsJSON))
-	JSONToBrowser(w, []byte(jsonOut))
+	return JSONToBrowser(w, []byte(jsonOut))
 }
 
 // ReportSuccess sends JSON message with "statusCode:1"
-func ReportSuccess(w http.ResponseWriter) {
+func ReportSuccess(w http.ResponseWriter) error {
 	jsonOut := fmt.Sprintf(`{"statusCode": %v }`, 1)
-	JSONToBrowser(w, []byte(jsonOut))
+	return JSONToBrowser(w, []byte(jsonOut))
 }
 
 // ReportJSON json.Marshals *results* and returns an error if that fails.
@@ -587,9 +689,9 @@ func ReportJSON(w http.ResponseWriter, results interface{}) error {
 
 // ReportReload sends JSON message with "statusCode:5", which doGetFetch/doPostFetch
 // interpret as a reload
-func ReportReload(w http.ResponseWriter) {
+func ReportReload(w http.ResponseWriter) error {
 	jsonOut := fmt.Sprintf(`{"statusCode": %v }`, 5)
-	JSONToBrowser(w, []byte(jsonOut))
+	return JSONToBrowser(w, []byte(jsonOut))
 }
 
 // GetFuncMap provides a set of utility functions to help format data on an HTML output page.
@@ -598,21 +700,24 @@ func GetFuncMap() map[string]interface{} {
 	var pluralizeInt = Pluralize[int]
 	var pluralizeInt64 = Pluralize[int64]
 
+	// Replaced with the request locale's by Renderer
+	var english = NewLocalizer(language.Und, nil)
+
 	return template.FuncMap{
-		"formatDate":                     FormatDate,
-		"formatDateLanguage":             FormatDateLanguage,
+		"formatDate":                     FormatDate,         //Month and day names in the request locale, bound by Renderer
+		"formatDateLanguage":             FormatDateLanguage, //"" for the request locale, bound by Renderer
 		"formatDateUTC":                  FormatDateUTC,
 		"displayDate":                    DisplayDate,
 		"displayMorningAfternoonEvening": DisplayMorningAfternoonEvening, //
 		"displayDateTime":                DisplayDateTime,
-		"dateFormatDisplay":              DateFormatDisplay,
-		"dateMonth":                      DateMonth,
+		"dateFormatDisplay":              DateFormatDisplay, //Month names in the request locale, bound by Renderer
+		"dateMonth":                      DateMonth,         //Request locale, bound by Renderer
 		"dateDay":                        DateDay,
 		"dateYear":                       DateYear,
-		"dateTimeFormal":                 DateTimeFormal,
-		"shortDateTime":                  ShortDateTime,
+		"dateTimeFormal":                 DateTimeFormal, //Request locale, bound by Renderer
+		"shortDateTime":                  ShortDateTime,  //Request locale, bound by Renderer
 		"renderFragment":                 RenderFragment,
-		"decimalDisplay0":                DecimalDisplay0, //Precision 6
+		"decimalDisplay0":                DecimalDisplay0, //Precision 6; separators of the request locale, bound by Renderer
 		"decimalDisplay2":                DecimalDisplay2, //Precision 6
 		"decimalDisplay3":                DecimalDisplay3, //Precision 6
 		"intDisplay0":                    IntDisplay0,
@@ -624,26 +729,31 @@ func GetFuncMap() map[string]interface{} {
 		"float64Display3":                Float64Display3,              //Precision 4
 		"int64Display2FromPrecision10":   Int64Display2FromPrecision10, //Precision 10
 		"fullDateTimeET":                 FullDateTimeET,               //
-		"whenCompletedDisplay":           WhenCompletedDisplay,         //
-		"whenRevisedDisplay":             WhenRevisedDisplay,           //
-		"issueDateFormatDisplay":         IssueDateFormatDisplay,       //
-		"marshal":                        Marshal,                      //
+		"whenCompletedDisplay":           WhenCompletedDisplay,         //"completed %s" is a catalog key when bound by Renderer
+		"whenRevisedDisplay":             WhenRevisedDisplay,           //", revised %s" is a catalog key when bound by Renderer
+		"issueDateFormatDisplay":         IssueDateFormatDisplay,       //Request locale, bound by Renderer
+		"marshal":                        MarshalJS,                    //Fails the render if v cannot be marshaled
+		"marshalFor":                     MarshalJSFor,                 //Only fields tagged render:"<group>"
 		"urlSafeKey":                     URLSafeKey,                   //
 		"keyToStringID":                  KeyToStringID,                //
 		"format2":                        Format2,                      //
-		"formatPhone":                    FormatPhone,                  //
+		"formatPhone":                    FormatPhone,                  //National format at home, international otherwise
+		"formatPhoneNational":            FormatPhoneNational,          //
+		"formatPhoneInternational":       FormatPhoneInternational,     //
+		"normalizePhone":                 NormalizePhone,               //E.164
+		"telURI":                         TelURI,                       //Complete tel: URI
 		"plusOne":                        PlusOne,                      //
 		"plusOne64":                      PlusOne64,                    //
 		"add":                            Add,                          //Add two numbers
 		"subtract":                       Subtract,                     //Subtract two numbers
 		"multiply":                       Multiply,
 		"divide":                         Divide,
-		"plusOneZeroPad":                 PlusOneZeroPad, //
-		"zeroPad":                        ZeroPad,        //
-		"zeroPad64":                      ZeroPad64,      //
-		"dashes":                         Dashes,         //
-		"fullDisplayDate":                FullDisplayDate,
-		"fullDateFormat":                 FullDateFormat, //
+		"plusOneZeroPad":                 PlusOneZeroPad,  //
+		"zeroPad":                        ZeroPad,         //
+		"zeroPad64":                      ZeroPad64,       //
+		"dashes":                         Dashes,          //
+		"fullDisplayDate":                FullDisplayDate, //Request locale, bound by Renderer
+		"fullDateFormat":                 FullDateFormat,  //Request locale, bound by Renderer
 		"timeFormatAmPm":                 TimeFormatAmPm,
 		"intlDateDisplay":                IntlDateDisplay, //
 		"firstInitial":                   FirstInitial,    //
@@ -651,23 +761,60 @@ func GetFuncMap() map[string]interface{} {
 		"isToday":                        IsToday,         //
 		"inFuture":                       InFuture,
 		"inPast":                         InPast,
-		"newLineToBR":                    NewLineToBR, //
+		"newLineToBR":                    NewLineToBR, //Escapes, then converts line breaks
+		"markdown":                       Markdown,    //Sanitized CommonMark
 		"timeFormat":                     TimeFormat,  //
 		"dict":                           DictHelper,  //
-		"htmlEscape":                     HTMLEscape,  //
-		"toUppercase":                    ToUppercase, //
-		"toLowercase":                    ToLowercase, //
+		"htmlEscape":                     HTMLEscape,  //Sanitizes with HTMLPolicy
+		"sanitizeHTML":                   SanitizeHTML,
+		"sanitizeUGC":                    SanitizeUGC,
+		"unsafeRawHTML":                  UnsafeRawHTML, //No checks at all: trusted, application-built markup only
+		"toUppercase":                    ToUppercase,   //
+		"toLowercase":                    ToLowercase,   //
 		"toTitleCase":                    ToTitleCase,
+		"uppercaseIn":                    UppercaseIn, //Locale-aware, e.g., {{uppercaseIn "tr" .Name}}
+		"lowercaseIn":                    LowercaseIn,
+		"titleCaseIn":                    TitleCaseIn,
+		"initials":                       Initials,
+		"truncate":                       Truncate,      //Grapheme-safe, adds an ellipsis
+		"truncateWords":                  TruncateWords, //Cuts at a word boundary
+		"slugify":                        Slugify,
 		"int64ToTime":                    Int64ToTime, //Converts, e.g., 835 to 8:35
 		"academicYearView":               utils.AcademicYearView,
-		"prepPhone":                      PrepPhone, //Preps a phone number for use in an HTML tel tag
-		"arrayToQS":                      ArrayToQS,
-		"precisionFormatter":             PrecisionFormatter,
-		"precisionFormatterFloat64":      PrecisionFormatterFloat64,
-		"pluralize":                      pluralizeInt,
-		"pluralizeInt64":                 pluralizeInt64,
-		"safe": func(s string) template.HTML {
-			return template.HTML(s)
+		"periodLabel":                    PeriodLabel, //Labels a date with its calendar period, e.g., "Fall 2025-26"
+		"periodStart":                    PeriodStart,
+		"periodEnd":                      PeriodEnd,
+		"schoolWeek":                     SchoolWeek,
+		"holidayName":                    HolidayName,
+		"prepPhone":                      PrepPhone, //E.164 (plus ;ext=) for use in an HTML tel tag
+		"arrayToQS":                      ArrayToQS, //Deprecated: use queryString/urlWith
+		"queryString":                    QueryString,
+		"urlWith":                        URLWith,    //Replace (or, with nil, remove) parameters
+		"urlAdd":                         URLAdd,     //Append values to repeated keys
+		"urlWithout":                     URLWithout, //Remove keys
+		"urlWithoutValue":                URLWithoutValue,
+		"currentURL":                     func() (*url.URL, error) { return nil, ErrNoRequest }, //Bound to the request by Renderer
+		"cspNonce":                       func() string { return "" },                           //Bound to the request by Renderer
+		"jsonScriptFor":                  JSONScriptFor,
+		"jsonScript":                     JSONScript,                                                            //<script type="application/json" id=...> data island
+		"csrfToken":                      func() (string, error) { return "", ErrNoCSRF },                       //Bound to the request by Renderer (see CSRF)
+		"csrfField":                      func() (template.HTML, error) { return template.HTML(""), ErrNoCSRF }, //Hidden input for forms
+		"t": func(key string, args ...interface{}) string { //Translates with the catalog; bound to the request locale by Renderer
+			return Translate(language.Und, key, args...)
 		},
+		"locale":                    func() *Localizer { return NewLocalizer(language.Und, nil) }, //Bound to the request locale by Renderer
+		"inLocale":                  InLocale,                                                     //Localizer for another language, e.g., {{with inLocale "fr"}}{{.T "Hi"}}; plain functions keep the request locale
+		"localeDate":                LocaleDate,                                                   //Month and day names in the request locale
+		"localeNumber":              LocaleNumber,                                                 //Separators of the request locale
+		"precisionFormatter":        PrecisionFormatter,
+		"precisionFormatterFloat64": PrecisionFormatterFloat64,
+		"pluralize":                 pluralizeInt,           //"one" and "other" by the request locale's rules, bound by Renderer
+		"pluralizeInt64":            pluralizeInt64,         //Request locale, bound by Renderer
+		"pluralCategory":            english.PluralCategory, //zero/one/two/few/many/other in the request locale
+		"plural":                    english.Plural,         //{{plural .Count "one" "car" "other" "cars"}} in the request locale
+		"pluralInt64":               english.PluralInt64,
+		"ordinal":                   english.Ordinal, //1st, 2nd; 1er, 2e in French
+		"ordinalInt64":              english.OrdinalInt64,
+		"safe":                      SanitizeHTML, //Kept for existing templates; use unsafeRawHTML for raw markup
 	}
 }
@@ -0,0 +1,452 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/language"
+)
+
+// Renderer executes the template sets produced by ParseTemplates
+// (or ParseTemplateSets), binding request-scoped helpers to each render.
+// Make one with NewRenderer, which copies every set before anything else
+// executes it, so the parsed sets can still be shared between goroutines.
+type Renderer struct {
+	// Templates maps a lookup name to its parsed set. The Renderer
+	// executes the copies NewRenderer made, so sets added or replaced
+	// afterwards are not rendered.
+	Templates map[string]*template.Template
+
+	// Clock is used when the request context carries none (see WithClock).
+	// Nil means DefaultClock.
+	Clock Clock
+
+	// CSP, when set, is sent as the Content-Security-Policy header of
+	// every page, with a fresh nonce available to templates as cspNonce.
+	CSP *CSP
+
+	// Catalog translates the t template function, in the locale carried
+	// by the request context (see WithLocale). Nil means DefaultCatalog.
+	Catalog *Catalog
+
+	// Locales, when set, negotiates the locale of requests whose context
+	// carries none. It drives t, locale, localeDate, localeNumber,
+	// formatDate, formatDateLanguage without a language, and the
+	// separators of the intDisplay, int64Display, decimalDisplay and
+	// float64Display helpers. Responses then vary on Accept-Language and
+	// the locale cookie.
+	Locales *Locales
+
+	// Observer is told the name, duration, size and error of every render.
+	// Nil means DefaultObserver.
+	Observer Observer
+
+	// Logger receives failed renders with the request's attributes.
+	// Nil means DefaultLogger.
+	Logger *slog.Logger
+
+	pools map[string]*setPool
+}
+
+// NewRenderer wraps *templates* in a Renderer using the package clock,
+// copying each set. It fails if a set has already been executed, e.g.,
+// by Template, since html/template can't copy it any more; call it
+// right after parsing.
+func NewRenderer(templates map[string]*template.Template) (*Renderer, error) {
+
+	rd := &Renderer{
+		Templates: templates,
+		pools:     make(map[string]*setPool, len(templates)),
+	}
+
+	for name, t := range templates {
+		clean, err := t.Clone()
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %v", name, err)
+		}
+		rd.pools[name] = &setPool{clean: clean}
+	}
+
+	return rd, nil
+}
+
+// Render executes the set registered under *name* with *model* and
+// sends the result to the browser. Nothing is written if execution
+// fails; the error is logged and returned as a *RenderError, and the
+// caller decides the response, e.g., with rd.ServerError.
+func (rd *Renderer) Render(w http.ResponseWriter, r *http.Request, name string, model interface{}) error {
+	return rd.render(w, r, name, model)
+}
+
+// render executes *blocks* of set *name* in order, or the whole set when
+// there are none, and sends the result
+func (rd *Renderer) render(w http.ResponseWriter, r *http.Request, name string, model interface{}, blocks ...string) (err error) {
+
+	if r != nil && rd.Locales != nil && LocaleFromContext(r.Context()) == language.Und {
+		rd.Locales.vary(w.Header())
+	}
+
+	r = rd.prepare(r)
+
+	observed := name
+	if len(blocks) > 0 {
+		observed = name + "#" + strings.Join(blocks, ",")
+	}
+
+	ctx, done := observe(r.Context(), rd.Observer, observed)
+	r = r.WithContext(ctx)
+
+	written := 0
+	defer func() {
+		done(written, err)
+		if err != nil {
+			logError(rd.logger(), r, "render failed", err, slog.String("template", name))
+			err = &RenderError{Template: name, Err: err}
+		}
+	}()
+
+	if rd.CSP != nil && CSPNonceFromContext(r.Context()) == "" {
+		nonce, err := NewCSPNonce()
+		if err != nil {
+			return err
+		}
+		r = r.WithContext(WithCSPNonce(r.Context(), nonce))
+	}
+
+	b, err := rd.execute(r, name, model, blocks...)
+	if err != nil {
+		return err
+	}
+
+	if rd.CSP != nil {
+		rd.CSP.Apply(w, CSPNonceFromContext(r.Context()))
+	}
+
+	if err = htmlToBrowser(w, b); err == nil {
+		written = len(b)
+	}
+
+	return err
+}
+
+// prepare attaches per-request state, such as the negotiated locale,
+// to the context of *r*.
+func (rd *Renderer) prepare(r *http.Request) *http.Request {
+
+	if r == nil {
+		// No URL, so currentURL fails rather than link to ""
+		r = &http.Request{Header: http.Header{}}
+	}
+
+	if rd.Locales != nil && LocaleFromContext(r.Context()) == language.Und {
+		r = r.WithContext(WithLocale(r.Context(), rd.Locales.Negotiate(r)))
+	}
+
+	return r
+}
+
+// ToHTML executes the set registered under *name* and returns the result
+// as template.HTML, for compositing fragments into a page.
+func (rd *Renderer) ToHTML(r *http.Request, name string, model interface{}) (template.HTML, error) {
+
+	r = rd.prepare(r)
+
+	ctx, done := observe(r.Context(), rd.Observer, name)
+
+	b, err := rd.execute(r.WithContext(ctx), name, model)
+	done(len(b), err)
+
+	if err != nil {
+		logError(rd.logger(), r, "render failed", err, slog.String("template", name))
+		err = &RenderError{Template: name, Err: err}
+		return template.HTML(""), err
+	}
+
+	return template.HTML(b), nil
+}
+
+// setPool hands out executable copies of one set, each with the
+// request-scoped functions bound once to its own execState, so a render
+// neither clones the set nor escapes it again
+type setPool struct {
+	clean *template.Template // never executed, so it can always be cloned
+	pool  sync.Pool
+}
+
+// instance is one copy of a set and the state its functions read
+type instance struct {
+	t     *template.Template
+	state *execState
+}
+
+// execState is what the request-scoped functions of an instance see
+// during one execution
+type execState struct {
+	r     *http.Request
+	ctx   context.Context
+	clock Clock
+	nonce string
+	loc   Localizer
+}
+
+// setPool returns the pool NewRenderer made for set *name*
+func (rd *Renderer) setPool(name string) (*setPool, error) {
+
+	if p, ok := rd.pools[name]; ok {
+		return p, nil
+	}
+
+	if rd.pools == nil {
+		return nil, fmt.Errorf("template %s: Renderer was not made by NewRenderer", name)
+	}
+
+	return nil, fmt.Errorf("template %s not found", name)
+}
+
+// instance returns an idle copy of set *name* bound to *r*; pass it to
+// release when done
+func (rd *Renderer) instance(r *http.Request, name string) (*setPool, *instance, error) {
+
+	p, err := rd.setPool(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inst, ok := p.pool.Get().(*instance)
+	if !ok {
+		clone, err := p.clean.Clone()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		inst = &instance{state: &execState{}}
+		inst.t = clone.Funcs(rd.requestFuncs(inst.state))
+	}
+
+	ctx := requestContext(r)
+	*inst.state = execState{
+		r:     r,
+		ctx:   ctx,
+		clock: rd.clockFor(ctx),
+		nonce: CSPNonceFromContext(ctx),
+		loc:   *NewLocalizer(LocaleFromContext(ctx), rd.Catalog),
+	}
+
+	return p, inst, nil
+}
+
+// release returns *inst* to *p*, dropping the request
+func (p *setPool) release(inst *instance) {
+	*inst.state = execState{}
+	p.pool.Put(inst)
+}
+
+// execute runs set *name*, or only its *blocks*, in order
+func (rd *Renderer) execute(r *http.Request, name string, model interface{}, blocks ...string) ([]byte, error) {
+
+	p, inst, err := rd.instance(r, name)
+	if err != nil {
+		return nil, err
+	}
+	defer p.release(inst)
+
+	t := inst.t
+	var b bytes.Buffer
+
+	if len(blocks) == 0 {
+		if err := t.Execute(&b, model); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+
+	for _, block := range blocks {
+		if t.Lookup(block) == nil {
+			return nil, fmt.Errorf("template %s has no block %s", name, block)
+		}
+		if err := t.ExecuteTemplate(&b, block, model); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+// requestFuncs returns the FuncMap entries whose behavior depends on the request.
+// They override the package-level versions registered by GetFuncMap, and
+// read *st*, which is set before each execution.
+func (rd *Renderer) requestFuncs(st *execState) template.FuncMap {
+
+	loc := &st.loc
+
+	// inZone formats in the package time zone with the request locale's month and day names
+	inZone := func(layout string) func(time.Time) string {
+		return func(val time.Time) string {
+			return loc.formatTime(val.In(location()), layout)
+		}
+	}
+
+	return template.FuncMap{
+		"isToday": func(dte time.Time) bool {
+			return isTodayAt(st.clock, dte)
+		},
+		"inFuture": func(dte time.Time, loc *time.Location) bool {
+			return inFutureAt(st.clock, dte, loc)
+		},
+		"inPast": func(dte time.Time, loc *time.Location) bool {
+			return inPastAt(st.clock, dte, loc)
+		},
+		"currentURL": func() (*url.URL, error) {
+			return currentURL(st.r)
+		},
+		"cspNonce": func() string {
+			return st.nonce
+		},
+		"csrfToken": func() (string, error) {
+			return csrfTokenFromContext(st.ctx)
+		},
+		"csrfField": func() (template.HTML, error) {
+			return csrfFieldFromContext(st.ctx)
+		},
+		"t": loc.T,
+		"locale": func() *Localizer {
+			l := st.loc
+			return &l
+		},
+		"inLocale": func(lang string) (*Localizer, error) {
+			tag, err := language.Parse(lang)
+			if err != nil {
+				return nil, err
+			}
+			return NewLocalizer(tag, rd.Catalog), nil
+		},
+		"localeDate":     loc.Date,
+		"localeNumber":   loc.Number,
+		"pluralCategory": loc.PluralCategory,
+		"plural":         loc.Plural,
+		"pluralInt64":    loc.PluralInt64,
+		"ordinal":        loc.Ordinal,
+		"ordinalInt64":   loc.OrdinalInt64,
+		"formatDate": func(val time.Time, location *time.Location, format string) string {
+			return loc.formatTime(val.In(location), format)
+		},
+		"formatDateLanguage": func(val time.Time, location *time.Location, format, lang string) string {
+			if lang == "" {
+				return loc.formatTime(val.In(location), format)
+			}
+			return FormatDateLanguage(val, location, format, lang)
+		},
+		"dateMonth":         inZone(layoutMonth),
+		"dateFormatDisplay": inZone(layoutMonthYear),
+		"shortDateTime":     inZone(layoutShortDateTime),
+		"dateTimeFormal":    inZone(layoutFormal),
+		"fullDisplayDate":   inZone(layoutFullDate),
+		"fullDateFormat": func(val time.Time, location *time.Location) string {
+			return loc.formatTime(val.In(location), layoutFullDate)
+		},
+		"issueDateFormatDisplay": func(val time.Time) string {
+			if val.IsZero() {
+				return ""
+			}
+			return loc.formatTime(val.In(location()), layoutMonthYear)
+		},
+		"whenCompletedDisplay": func(val time.Time) string {
+			if val.IsZero() {
+				return ""
+			}
+			return loc.T("completed %s", loc.formatTime(val.In(location()), layoutMonthYear))
+		},
+		"whenRevisedDisplay": func(val time.Time) string {
+			if val.IsZero() {
+				return ""
+			}
+			return loc.T(", revised %s", loc.formatTime(val.In(location()), layoutMonthYear))
+		},
+		"pluralize": func(count int, oneItem, otherItems string) string {
+			text, _ := loc.Plural(count, "one", oneItem, "other", otherItems)
+			return text
+		},
+		"pluralizeInt64": func(count int64, oneItem, otherItems string) string {
+			text, _ := loc.PluralInt64(count, "one", oneItem, "other", otherItems)
+			return text
+		},
+		"intDisplay0": func(number int) string {
+			return loc.localizeDigits(IntDisplay0(number))
+		},
+		"int64Display0": func(number int64) string {
+			return loc.localizeDigits(Int64Display0(number))
+		},
+		"int64Display2": func(number int64) string {
+			return loc.localizeDigits(Int64Display2(number))
+		},
+		"int64Display3": func(number int64) string {
+			return loc.localizeDigits(Int64Display3(number))
+		},
+		"int64Display2FromPrecision10": func(number int64) string {
+			return loc.localizeDigits(Int64Display2FromPrecision10(number))
+		},
+		"decimalDisplay0": func(dec decimal.Decimal) string {
+			return loc.localizeDigits(DecimalDisplay0(dec))
+		},
+		"decimalDisplay2": func(dec decimal.Decimal) string {
+			return loc.localizeDigits(DecimalDisplay2(dec))
+		},
+		"decimalDisplay3": func(dec decimal.Decimal) string {
+			return loc.localizeDigits(DecimalDisplay3(dec))
+		},
+		"float64Display0": func(number float64) string {
+			return loc.Sprintf("%.0f", number/10000)
+		},
+		"float64Display2": func(number float64) string {
+			return loc.Sprintf("%.2f", number/10000)
+		},
+		"float64Display3": func(number float64) string {
+			return loc.Sprintf("%.3f", number/10000)
+		},
+	}
+}
+
+// clockFor prefers a clock set on the context, then the Renderer's, then the package default
+func (rd *Renderer) clockFor(ctx context.Context) Clock {
+	if c, ok := ctx.Value(clockKey{}).(Clock); ok && c != nil {
+		return c
+	}
+
+	if rd.Clock != nil {
+		return rd.Clock
+	}
+
+	return DefaultClock()
+}
+
+// Now returns the current time as seen by the render of *r*
+func (rd *Renderer) Now(r *http.Request) time.Time {
+	return rd.clockFor(requestContext(r)).Now()
+}
+
+// currentURL returns a copy of the path and query of *r*, for urlWith
+// and friends, or ErrNoRequest when there is no request
+func currentURL(r *http.Request) (*url.URL, error) {
+	if r == nil || r.URL == nil {
+		return nil, ErrNoRequest
+	}
+
+	return &url.URL{Path: r.URL.Path, RawQuery: r.URL.RawQuery}, nil
+}
+
+func requestContext(r *http.Request) context.Context {
+	if r == nil {
+		return context.Background()
+	}
+
+	return r.Context()
+}
@@ -0,0 +1,274 @@
+package render
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/language"
+)
+
+func TestRendererLocaleHelpers(t *testing.T) {
+
+	const page = `{{formatDate .When .Zone "Monday 2 January"}}|{{formatDateLanguage .When .Zone "January" ""}}|` +
+		`{{formatDateLanguage .When .Zone "January" "fr_FR"}}|{{intDisplay0 .Int}}|{{int64Display2 .Int64}}|` +
+		`{{decimalDisplay2 .Decimal}}|{{float64Display2 .Float}}`
+
+	tmpl := template.Must(template.New("page").Funcs(GetFuncMap()).Parse(page))
+
+	model := struct {
+		When    time.Time
+		Zone    *time.Location
+		Int     int
+		Int64   int64
+		Decimal decimal.Decimal
+		Float   float64
+	}{
+		When:    time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC),
+		Zone:    time.UTC,
+		Int:     1234567,
+		Int64:   123456789012345, // 12,345,678,901.2345 at precision 4
+		Decimal: decimal.RequireFromString("-1234.567"),
+		Float:   12345678,
+	}
+
+	tests := []struct {
+		name    string
+		locales *Locales
+		accept  string
+		want    string
+		vary    []string
+	}{
+		{
+			name: "no negotiation",
+			want: "Monday 4 March|March|mars|1,234,567|12,345,678,901.23|-1,234.57|1,234.57",
+		},
+		{
+			name:    "english",
+			locales: &Locales{Supported: []language.Tag{language.English, language.German}},
+			accept:  "en-US",
+			want:    "Monday 4 March|March|mars|1,234,567|12,345,678,901.23|-1,234.57|1,234.57",
+			vary:    []string{"Accept-Language", "Cookie"},
+		},
+		{
+			name:    "german",
+			locales: &Locales{Supported: []language.Tag{language.English, language.German}},
+			accept:  "de-DE,de;q=0.9",
+			want:    "Montag 4 März|März|mars|1.234.567|12.345.678.901,23|-1.234,57|1.234,57",
+			vary:    []string{"Accept-Language", "Cookie"},
+		},
+		{
+			name:    "without cookie",
+			locales: &Locales{Supported: []language.Tag{language.English, language.German}, CookieName: "-"},
+			accept:  "de",
+			want:    "Montag 4 März|März|mars|1.234.567|12.345.678.901,23|-1.234,57|1.234,57",
+			vary:    []string{"Accept-Language"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rd := newRenderer(t, map[string]*template.Template{"page": tmpl})
+			rd.Locales = tt.locales
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Language", tt.accept)
+			w := httptest.NewRecorder()
+
+			if err := rd.Render(w, r, "page", model); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+
+			if got := w.Header().Values("Vary"); strings.Join(got, ", ") != strings.Join(tt.vary, ", ") {
+				t.Errorf("Vary = %q, want %q", got, tt.vary)
+			}
+		})
+	}
+}
+
+// A locale already in the context was not negotiated by the Renderer
+func TestRendererContextLocaleDoesNotVary(t *testing.T) {
+
+	tmpl := template.Must(template.New("page").Funcs(GetFuncMap()).Parse(`{{intDisplay0 .}}`))
+	rd := newRenderer(t, map[string]*template.Template{"page": tmpl})
+	rd.Locales = &Locales{Supported: []language.Tag{language.English, language.French}}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(WithLocale(r.Context(), language.French))
+	w := httptest.NewRecorder()
+
+	if err := rd.Render(w, r, "page", 1234567); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := w.Body.String(); got != "1\u00a0234\u00a0567" {
+		t.Errorf("got %q", got)
+	}
+	if got := w.Header().Values("Vary"); len(got) != 0 {
+		t.Errorf("Vary = %q", got)
+	}
+}
+
+func TestCurrentURL(t *testing.T) {
+
+	tmpl := template.Must(template.New("page").Funcs(GetFuncMap()).Parse(
+		`{{define "next"}}{{urlWith currentURL "page" 2}}{{end}}{{template "next"}}`))
+	rd := newRenderer(t, map[string]*template.Template{"page": tmpl})
+
+	w := httptest.NewRecorder()
+	if err := rd.Render(w, httptest.NewRequest(http.MethodGet, "/orders?sort=name&page=1", nil), "page", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Body.String(); got != "/orders?page=2&amp;sort=name" {
+		t.Errorf("got %q", got)
+	}
+
+	if err := rd.RenderBlock(httptest.NewRecorder(), "page", "next", nil); !errors.Is(err, ErrNoRequest) {
+		t.Errorf("RenderBlock = %v, want ErrNoRequest", err)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, nil); !errors.Is(err, ErrNoRequest) {
+		t.Errorf("outside a Renderer = %v, want ErrNoRequest", err)
+	}
+}
+
+func newRenderer(t *testing.T, templates map[string]*template.Template) *Renderer {
+	t.Helper()
+
+	rd, err := NewRenderer(templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rd
+}
+
+func TestNewRenderer(t *testing.T) {
+
+	parse := func() *template.Template {
+		return template.Must(template.New("page").Funcs(GetFuncMap()).Parse(`<p>{{.}}</p>`))
+	}
+
+	// A set executed before NewRenderer can't be copied
+	executed := parse()
+	if err := executed.Execute(&strings.Builder{}, "x"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRenderer(map[string]*template.Template{"page": executed}); err == nil {
+		t.Error("NewRenderer of an executed set: no error")
+	}
+
+	// Executing the parsed set afterwards doesn't affect the Renderer
+	tmpl := parse()
+	rd := newRenderer(t, map[string]*template.Template{"page": tmpl})
+	if err := tmpl.Execute(&strings.Builder{}, "x"); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	if err := rd.Render(w, httptest.NewRequest(http.MethodGet, "/", nil), "page", "y"); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Body.String(); got != "<p>y</p>" {
+		t.Errorf("got %q", got)
+	}
+
+	// Pages are HTML and not shared with other origins
+	if got := w.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	for _, h := range []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Credentials"} {
+		if got := w.Header().Get(h); got != "" {
+			t.Errorf("%s = %q", h, got)
+		}
+	}
+
+	if err := rd.Render(httptest.NewRecorder(), nil, "missing", nil); err == nil {
+		t.Error("missing set: no error")
+	}
+
+	literal := &Renderer{Templates: map[string]*template.Template{"page": parse()}}
+	if err := literal.Render(httptest.NewRecorder(), nil, "page", nil); err == nil || !strings.Contains(err.Error(), "NewRenderer") {
+		t.Errorf("Renderer literal: got %v", err)
+	}
+}
+
+func TestRendererLocaleDatesAndPlurals(t *testing.T) {
+
+	const page = `{{dateMonth .When}}|{{dateFormatDisplay .When}}|{{shortDateTime .When}}|{{dateTimeFormal .When}}|` +
+		`{{fullDisplayDate .When}}|{{fullDateFormat .When .Zone}}|{{whenCompletedDisplay .When}}|` +
+		`{{issueDateFormatDisplay .When}}{{issueDateFormatDisplay .Zero}}|` +
+		`{{pluralize 0 "file" "files"}} {{pluralize 1 "file" "files"}} {{pluralizeInt64 2 "file" "files"}}`
+
+	tmpl := template.Must(template.New("page").Funcs(GetFuncMap()).Parse(page))
+
+	model := struct {
+		When time.Time
+		Zone *time.Location
+		Zero time.Time
+	}{
+		When: time.Date(2024, 3, 4, 17, 5, 0, 0, time.UTC), // 12:05pm in New York
+		Zone: time.UTC,
+	}
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "Mar|March 2024|Mar 04 12:05pm|March 04, 2024 at 12:05pm|" +
+			"Monday, March 4, 2024|Monday, March 4, 2024|completed March 2024|March 2024|files file files"},
+		{"de", "Mär|März 2024|Mär 04 12:05pm|März 04, 2024 at 12:05pm|" +
+			"Montag, März 4, 2024|Montag, März 4, 2024|completed März 2024|März 2024|files file files"},
+		// French counts zero as singular
+		{"fr", "mars|mars 2024|mars 04 12:05pm|mars 04, 2024 at 12:05pm|" +
+			"lundi, mars 4, 2024|lundi, mars 4, 2024|completed mars 2024|mars 2024|file file files"},
+	}
+
+	for _, tt := range tests {
+		rd := newRenderer(t, map[string]*template.Template{"page": tmpl})
+		rd.Locales = &Locales{Supported: []language.Tag{language.English, language.German, language.French}}
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Language", tt.accept)
+		w := httptest.NewRecorder()
+
+		if err := rd.Render(w, r, "page", model); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("%q:\ngot  %s\nwant %s", tt.accept, got, tt.want)
+		}
+	}
+}
+
+// inLocale changes the Localizer it returns, not the functions in its block
+func TestRendererInLocale(t *testing.T) {
+
+	tmpl := template.Must(template.New("page").Funcs(GetFuncMap()).Parse(
+		`{{with inLocale "de"}}{{.Date $.When "January"}} {{.Number 1234.5 1}}|{{localeDate $.When "January"}} {{intDisplay0 1234}}{{end}}`))
+	rd := newRenderer(t, map[string]*template.Template{"page": tmpl})
+	rd.Locales = &Locales{Supported: []language.Tag{language.English, language.French}}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Language", "fr")
+	w := httptest.NewRecorder()
+
+	if err := rd.Render(w, r, "page", map[string]time.Time{"When": time.Date(2024, 3, 4, 17, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := w.Body.String(), "März 1.234,5|mars 1\u00a0234"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
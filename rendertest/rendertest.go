@@ -0,0 +1,311 @@
+// Package rendertest renders templates with fixture models and compares
+// the output against golden files, so every page gets a regression test:
+//
+//	func TestDashboard(t *testing.T) {
+//		h := rendertest.New(renderer)
+//
+//		var model DashboardModel
+//		rendertest.LoadFixture(t, "testdata/dashboard.yaml", &model)
+//
+//		h.Assert(t, "dashboard", model) // compares with testdata/dashboard.golden
+//	}
+//
+// Run the tests with -rendertest.update to write the golden files from
+// the current output.
+// Renders use a fixed clock, locale and CSP nonce, so the output depends
+// only on the templates and the model.
+package rendertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjbigler/render"
+	"github.com/bjbigler/render/check"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+// The flag is namespaced so test binaries can still define their own -update
+var update = flag.Bool("rendertest.update", false, "rewrite golden files with the current render output")
+
+// Epoch is the instant the default clock is fixed at: a Monday morning
+// in New York, during a school term.
+var Epoch = time.Date(2024, time.January, 15, 9, 30, 0, 0, time.FixedZone("EST", -5*60*60))
+
+// Nonce is the CSP nonce every render sees
+const Nonce = "cmVuZGVydGVzdA=="
+
+// Harness renders the sets of a Renderer deterministically
+type Harness struct {
+	Renderer *render.Renderer
+
+	// Dir holds the golden files; defaults to "testdata"
+	Dir string
+
+	// Clock drives isToday, inFuture and inPast; defaults to Epoch
+	Clock render.Clock
+
+	// Locale drives t, locale and the locale-aware helpers; defaults to English
+	Locale language.Tag
+
+	// Request is the request pages are rendered for, e.g., to test
+	// currentURL; defaults to GET /
+	Request *http.Request
+}
+
+// New returns a Harness for *rd* with the default clock and locale
+func New(rd *render.Renderer) *Harness {
+	return &Harness{
+		Renderer: rd,
+		Dir:      "testdata",
+		Clock:    render.FixedClock(Epoch),
+		Locale:   language.English,
+	}
+}
+
+// Render executes set *name* with *model* and returns the output,
+// failing the test if execution fails
+func (h *Harness) Render(t testing.TB, name string, model interface{}) string {
+	t.Helper()
+
+	out, err := h.Renderer.ToHTML(h.request(), name, model)
+	if err != nil {
+		t.Fatalf("render %s: %v", name, err)
+	}
+
+	return string(out)
+}
+
+// Assert renders set *name* and compares it with <Dir>/<name>.golden
+func (h *Harness) Assert(t testing.TB, name string, model interface{}) {
+	t.Helper()
+
+	h.AssertGolden(t, name, name, model)
+}
+
+// AssertGolden renders set *name* and compares it with <Dir>/<golden>.golden,
+// for testing one page with several fixtures, e.g., "dashboard-empty".
+// With -rendertest.update, the golden file is written instead.
+func (h *Harness) AssertGolden(t testing.TB, golden, name string, model interface{}) {
+	t.Helper()
+
+	got := h.Render(t, name, model)
+	path := filepath.Join(h.dir(), golden+".golden")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("%s does not exist; run the test with -rendertest.update to create it", path)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := Diff(Normalize(string(want)), Normalize(got)); d != "" {
+		t.Errorf("%s differs from %s (-want +got):\n%s", name, path, d)
+	}
+}
+
+func (h *Harness) dir() string {
+	if h.Dir == "" {
+		return "testdata"
+	}
+
+	return h.Dir
+}
+
+// request returns the request to render with, carrying the fixed clock,
+// locale and nonce
+func (h *Harness) request() *http.Request {
+
+	r := h.Request
+	if r == nil {
+		r = httptest.NewRequest(http.MethodGet, "/", nil)
+	}
+
+	clock := h.Clock
+	if clock == nil {
+		clock = render.FixedClock(Epoch)
+	}
+
+	locale := h.Locale
+	if locale == language.Und {
+		locale = language.English
+	}
+
+	ctx := render.WithClock(r.Context(), clock)
+	ctx = render.WithLocale(ctx, locale)
+	ctx = render.WithCSPNonce(ctx, Nonce)
+
+	return r.WithContext(ctx)
+}
+
+// WithContext returns a copy of *h* whose request carries *ctx*, e.g.,
+// to render as a signed-in user
+func (h *Harness) WithContext(ctx context.Context) *Harness {
+	r := h.Request
+	if r == nil {
+		r = httptest.NewRequest(http.MethodGet, "/", nil)
+	}
+
+	c := *h
+	c.Request = r.WithContext(ctx)
+
+	return &c
+}
+
+// TypeCheck fails the test for each problem check.TypeCheck finds in
+// the sets of the Renderer, given the model of each set
+func (h *Harness) TypeCheck(t testing.TB, models check.Models) {
+	t.Helper()
+
+	findings, err := check.TypeCheck(h.Renderer.Templates, models)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range findings {
+		t.Error(f)
+	}
+}
+
+// LoadFixture decodes the JSON or YAML file at *path* into *v*. YAML is
+// decoded through JSON, so models only need json tags.
+func LoadFixture(t testing.TB, path string, v interface{}) {
+	t.Helper()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		var doc interface{}
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if b, err = json.Marshal(doc); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+	case ".json":
+	default:
+		t.Fatalf("%s: fixtures must be .json, .yaml or .yml", path)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+}
+
+// Normalize makes whitespace-only changes compare equal: runs of
+// whitespace, line breaks included, collapse to one space, and whitespace
+// between tags is dropped. The result has one tag boundary per line, so
+// diffs stay readable. Whitespace inside <pre> is normalized too.
+func Normalize(s string) string {
+
+	s = strings.Join(strings.Fields(s), " ")
+	s = betweenTags.ReplaceAllString(s, "><")
+
+	return strings.ReplaceAll(s, "><", ">\n<")
+}
+
+var betweenTags = regexp.MustCompile(`>\s+<`)
+
+// maxDiffLines bounds the quadratic line diff; larger outputs are
+// reported from the first differing line instead
+const maxDiffLines = 4000
+
+// Diff returns a line diff of *want* and *got*, "" if they are equal.
+// Removed lines start with "-", added lines with "+".
+func Diff(want, got string) string {
+
+	if want == got {
+		return ""
+	}
+
+	a := strings.Split(want, "\n")
+	b := strings.Split(got, "\n")
+
+	if len(a)*len(b) > maxDiffLines*maxDiffLines/4 {
+		return firstDifference(a, b)
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&sb, "%d: - %s\n", i+1, a[i])
+			i++
+		default:
+			fmt.Fprintf(&sb, "%d: + %s\n", j+1, b[j])
+			j++
+		}
+	}
+
+	return sb.String()
+}
+
+func firstDifference(a, b []string) string {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y string
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y || i >= len(a) || i >= len(b) {
+			return fmt.Sprintf("first difference at line %d:\n- %s\n+ %s\n", i+1, x, y)
+		}
+	}
+
+	return ""
+}
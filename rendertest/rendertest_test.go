@@ -0,0 +1,286 @@
+package rendertest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjbigler/render"
+)
+
+type order struct {
+	Number string    `json:"number"`
+	Placed time.Time `json:"placed"`
+}
+
+type ordersModel struct {
+	Title  string  `json:"title"`
+	Orders []order `json:"orders"`
+}
+
+func newHarness(t *testing.T) *Harness {
+	t.Helper()
+
+	templates, err := render.ParseTemplates(
+		[][]string{{"orders", "testdata/views/orders.html"}},
+		"testdata/views/master.html",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rd, err := render.NewRenderer(templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return New(rd)
+}
+
+// recorder captures the failures of a test helper run by capture
+type recorder struct {
+	testing.TB
+	fatal  string
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Fatal(args ...interface{}) {
+	r.fatal = fmt.Sprint(args...)
+	runtime.Goexit()
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.fatal = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// capture runs *f* with a recorder, which stops it on the first fatal failure
+func capture(t *testing.T, f func(tb testing.TB)) *recorder {
+	rec := &recorder{TB: t}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f(rec)
+	}()
+	<-done
+
+	return rec
+}
+
+func TestHarnessRendersParseTemplatesSets(t *testing.T) {
+	h := newHarness(t)
+
+	var model ordersModel
+	LoadFixture(t, "testdata/orders.yaml", &model)
+
+	got := Normalize(h.Render(t, "orders", model))
+
+	for _, want := range []string{
+		`<html lang="en">`,
+		`<title>Recent orders</title>`,
+		`<script nonce="` + Nonce + `">window.page = "Recent orders"</script>`,
+		`<li> A-100 placed Jan 15, 2024 (today) </li>`,
+		`<li> A-099 placed Jan 12, 2024 </li>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output lacks %s:\n%s", want, got)
+		}
+	}
+}
+
+func TestHarnessRendersRepeatedly(t *testing.T) {
+	h := newHarness(t)
+	model := ordersModel{Title: "Orders"}
+
+	first := h.Render(t, "orders", model)
+	if second := h.Render(t, "orders", model); second != first {
+		t.Errorf("second render differs:\n%s", Diff(first, second))
+	}
+}
+
+func TestAssertGoldenUpdate(t *testing.T) {
+	h := newHarness(t)
+	h.Dir = t.TempDir()
+	model := ordersModel{Title: "Orders", Orders: []order{{Number: "A-1", Placed: Epoch}}}
+	path := filepath.Join(h.Dir, "orders-one.golden")
+
+	rec := capture(t, func(tb testing.TB) { h.AssertGolden(tb, "orders-one", "orders", model) })
+	if !strings.Contains(rec.fatal, "does not exist") {
+		t.Fatalf("missing golden file: got fatal %q", rec.fatal)
+	}
+
+	*update = true
+	h.AssertGolden(t, "orders-one", "orders", model)
+	*update = false
+
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(written) != h.Render(t, "orders", model) {
+		t.Errorf("golden file is not the render output:\n%s", written)
+	}
+
+	// Whitespace-only changes still match
+	if err := os.WriteFile(path, []byte(strings.ReplaceAll(string(written), "\n", "\n\n  ")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h.AssertGolden(t, "orders-one", "orders", model)
+
+	model.Orders[0].Number = "A-2"
+	rec = capture(t, func(tb testing.TB) { h.AssertGolden(tb, "orders-one", "orders", model) })
+	if len(rec.errors) != 1 || !strings.Contains(rec.errors[0], "- <li> A-1 placed") || !strings.Contains(rec.errors[0], "+ <li> A-2 placed") {
+		t.Errorf("changed render: got errors %q", rec.errors)
+	}
+}
+
+func TestLoadFixture(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    ordersModel
+		fatal   string
+	}{
+		{
+			name:    "yaml",
+			file:    "a.yaml",
+			content: "title: T\norders:\n  - number: N-1\n    placed: 2024-01-15T09:30:00Z\n",
+			want:    ordersModel{Title: "T", Orders: []order{{Number: "N-1", Placed: time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)}}},
+		},
+		{
+			name:    "yml",
+			file:    "a.yml",
+			content: "title: T\n",
+			want:    ordersModel{Title: "T"},
+		},
+		{
+			name:    "json",
+			file:    "a.json",
+			content: `{"title": "T", "orders": [{"number": "N-1"}]}`,
+			want:    ordersModel{Title: "T", Orders: []order{{Number: "N-1"}}},
+		},
+		{
+			name:    "unknown field",
+			file:    "b.yaml",
+			content: "title: T\ntotal: 3\n",
+			fatal:   `unknown field "total"`,
+		},
+		{
+			name:    "invalid yaml",
+			file:    "c.yaml",
+			content: "title: [\n",
+			fatal:   "c.yaml",
+		},
+		{
+			name:    "other extension",
+			file:    "a.toml",
+			content: `title = "T"`,
+			fatal:   "must be .json, .yaml or .yml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := write(tt.file, tt.content)
+
+			var got ordersModel
+			rec := capture(t, func(tb testing.TB) { LoadFixture(tb, path, &got) })
+
+			if tt.fatal != "" {
+				if !strings.Contains(rec.fatal, tt.fatal) {
+					t.Errorf("got fatal %q, want it to contain %q", rec.fatal, tt.fatal)
+				}
+				return
+			}
+
+			if rec.fatal != "" {
+				t.Fatal(rec.fatal)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	rec := capture(t, func(tb testing.TB) { LoadFixture(tb, filepath.Join(dir, "missing.json"), &ordersModel{}) })
+	if rec.fatal == "" {
+		t.Error("missing file: no failure")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"<p>a</p>", "<p>a</p>"},
+		{"<p>\n  a\n  b\n</p>", "<p> a b </p>"},
+		{"<ul>\n  <li>a</li>\n\n  <li>b</li>\n</ul>\n", "<ul>\n<li>a</li>\n<li>b</li>\n</ul>"},
+		{"<ul><li>a</li><li>b</li></ul>", "<ul>\n<li>a</li>\n<li>b</li>\n</ul>"},
+		{"\t<b>x</b>  <i>y</i>\r\n", "<b>x</b>\n<i>y</i>"},
+		{"<pre>a\n  b</pre>", "<pre>a b</pre>"},
+		{"a  <b>b</b>  c", "a <b>b</b> c"},
+	}
+
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name, want, got, diff string
+	}{
+		{"equal", "a\nb", "a\nb", ""},
+		{"changed", "a\nb\nc", "a\nx\nc", "2: - b\n2: + x\n"},
+		{"added", "a\nc", "a\nb\nc", "2: + b\n"},
+		{"removed", "a\nb\nc", "a\nc", "2: - b\n"},
+		{"replaced all", "a", "b", "1: - a\n1: + b\n"},
+		{"from empty", "", "a", "1: - \n1: + a\n"},
+	}
+
+	for _, tt := range tests {
+		if d := Diff(tt.want, tt.got); d != tt.diff {
+			t.Errorf("%s: Diff = %q, want %q", tt.name, d, tt.diff)
+		}
+	}
+}
+
+func TestDiffLargeReportsFirstDifference(t *testing.T) {
+	lines := make([]string, maxDiffLines)
+	for i := range lines {
+		lines[i] = fmt.Sprint(i)
+	}
+
+	want := strings.Join(lines, "\n")
+	lines[10] = "changed"
+	got := strings.Join(lines, "\n")
+
+	if d := Diff(want, got); d != "first difference at line 11:\n- 10\n+ changed\n" {
+		t.Errorf("Diff = %q", d)
+	}
+}
@@ -0,0 +1,372 @@
+package render
+
+import (
+	"html/template"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Policy is an allowlist of HTML elements, attributes and URL schemes.
+// Anything not listed is removed: disallowed elements are dropped but
+// their text is kept, except for elements such as <script> and <style>
+// whose content is dropped too. Comments and doctypes are always removed.
+type Policy struct {
+	// Elements maps an allowed tag name to the attributes allowed on it
+	Elements map[string][]string
+
+	// GlobalAttributes are allowed on every allowed element
+	GlobalAttributes []string
+
+	// URLSchemes are the schemes allowed in href and src values.
+	// Relative URLs and fragments are always allowed.
+	URLSchemes []string
+
+	// LinkRel, when set, replaces the rel attribute of every link
+	LinkRel string
+
+	// LinkTarget, when set, replaces the target attribute of every link.
+	// "_blank" also adds "noopener" to rel.
+	LinkTarget string
+}
+
+// dropContent lists elements whose content is never rendered as text
+var dropContent = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"object":   true,
+	"embed":    true,
+	"noscript": true,
+	"template": true,
+	"textarea": true,
+	"select":   true,
+	"title":    true,
+	"svg":      true,
+	"math":     true,
+}
+
+// foreignElements are the dropContent elements that may be self-closing
+var foreignElements = map[string]bool{
+	"svg":  true,
+	"math": true,
+}
+
+var voidElements = map[string]bool{
+	"br":    true,
+	"hr":    true,
+	"img":   true,
+	"col":   true,
+	"wbr":   true,
+	"input": true,
+}
+
+var urlAttributes = map[string]bool{
+	"href":   true,
+	"src":    true,
+	"cite":   true,
+	"action": true,
+}
+
+// Sanitize returns *s* with everything outside the policy removed
+func (p *Policy) Sanitize(s string) string {
+
+	z := html.NewTokenizer(strings.NewReader(s))
+
+	var (
+		sb    strings.Builder
+		open  []string
+		skip  int
+		token html.Token
+	)
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() != io.EOF {
+				return ""
+			}
+			break
+		}
+
+		token = z.Token()
+		name := token.Data
+
+		switch tt {
+		case html.TextToken:
+			if skip == 0 {
+				sb.WriteString(html.EscapeString(token.Data))
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if dropContent[name] {
+				// Browsers ignore the slash of <script/> and the like, so
+				// what follows is still their content; only svg and math
+				// can really be self-closing
+				if tt == html.StartTagToken || !foreignElements[name] {
+					skip++
+				}
+				continue
+			}
+
+			if skip > 0 || !p.allows(name) {
+				continue
+			}
+
+			p.writeStartTag(&sb, token)
+
+			if tt == html.StartTagToken && !voidElements[name] {
+				open = append(open, name)
+			}
+
+		case html.EndTagToken:
+			if dropContent[name] {
+				if skip > 0 {
+					skip--
+				}
+				continue
+			}
+
+			if skip > 0 || !p.allows(name) {
+				continue
+			}
+
+			// Close back to the matching element; stray end tags are dropped
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != name {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					sb.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		sb.WriteString("</" + open[i] + ">")
+	}
+
+	return sb.String()
+}
+
+// SanitizeHTML sanitizes *s* and returns it ready to place in a template
+func (p *Policy) SanitizeHTML(s string) template.HTML {
+	return template.HTML(p.Sanitize(s))
+}
+
+func (p *Policy) allows(element string) bool {
+	_, ok := p.Elements[element]
+	return ok
+}
+
+func (p *Policy) allowsAttribute(element, attribute string) bool {
+	for _, a := range p.Elements[element] {
+		if a == attribute {
+			return true
+		}
+	}
+
+	for _, a := range p.GlobalAttributes {
+		if a == attribute {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowsURL accepts relative URLs and URLs whose scheme is in URLSchemes
+func (p *Policy) allowsURL(v string) bool {
+	// Browsers read "\" as "/", so "/\host" is protocol-relative too
+	u, err := url.Parse(strings.ReplaceAll(strings.TrimSpace(v), `\`, "/"))
+	if err != nil {
+		return false
+	}
+
+	if u.Scheme == "" {
+		// "//host/path" is protocol-relative: treat as external and allow
+		// only when http or https is allowed
+		if u.Host != "" {
+			return p.allowsScheme("https") || p.allowsScheme("http")
+		}
+		return true
+	}
+
+	return p.allowsScheme(strings.ToLower(u.Scheme))
+}
+
+func (p *Policy) allowsScheme(scheme string) bool {
+	for _, s := range p.URLSchemes {
+		if strings.EqualFold(s, scheme) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Policy) writeStartTag(sb *strings.Builder, token html.Token) {
+
+	name := token.Data
+	isLink := false
+	rel := p.LinkRel
+
+	sb.WriteString("<" + name)
+
+	for _, a := range token.Attr {
+		key := strings.ToLower(a.Key)
+
+		if a.Namespace != "" || !p.allowsAttribute(name, key) {
+			continue
+		}
+
+		if urlAttributes[key] && !p.allowsURL(a.Val) {
+			continue
+		}
+
+		if name == "a" && key == "href" {
+			isLink = true
+		}
+
+		if name == "a" && key == "rel" && (p.LinkRel != "" || p.LinkTarget != "") {
+			if p.LinkRel == "" {
+				rel = a.Val // kept, and written with the other link attributes
+			}
+			continue
+		}
+
+		if name == "a" && key == "target" && p.LinkTarget != "" {
+			continue
+		}
+
+		writeAttribute(sb, key, a.Val)
+	}
+
+	if isLink {
+		if p.LinkTarget == "_blank" && !strings.Contains(rel, "noopener") {
+			rel = strings.TrimSpace(rel + " noopener")
+		}
+
+		if rel != "" {
+			writeAttribute(sb, "rel", rel)
+		}
+		if p.LinkTarget != "" {
+			writeAttribute(sb, "target", p.LinkTarget)
+		}
+	}
+
+	if voidElements[name] {
+		sb.WriteString(" />")
+		return
+	}
+
+	sb.WriteString(">")
+}
+
+func writeAttribute(sb *strings.Builder, key, val string) {
+	sb.WriteString(" " + key + `="` + html.EscapeString(val) + `"`)
+}
+
+// AllowElement adds *element* with *attributes* to the policy and returns
+// it, so policies can be built up from one of the presets:
+//
+//	p := render.UGCPolicy().AllowElement("details").AllowElement("summary")
+func (p *Policy) AllowElement(element string, attributes ...string) *Policy {
+	if p.Elements == nil {
+		p.Elements = make(map[string][]string)
+	}
+
+	p.Elements[element] = append(p.Elements[element], attributes...)
+
+	return p
+}
+
+// StrictPolicy allows no markup at all; only text survives
+func StrictPolicy() *Policy {
+	return &Policy{Elements: map[string][]string{}}
+}
+
+// HTMLPolicy allows the formatting, list, table, link and image markup
+// produced by rich-text editors, plus class attributes.
+// Scripts, styles, event handlers and javascript: URLs are removed.
+func HTMLPolicy() *Policy {
+	return &Policy{
+		Elements: map[string][]string{
+			"p": nil, "br": nil, "hr": nil, "div": nil, "span": nil,
+			"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+			"b": nil, "i": nil, "u": nil, "s": nil, "em": nil, "strong": nil,
+			"del": nil, "ins": nil, "sub": nil, "sup": nil, "small": nil, "mark": nil,
+			"abbr": {"title"}, "q": {"cite"}, "blockquote": {"cite"},
+			"pre": nil, "code": nil,
+			"ul": nil, "ol": {"start", "type"}, "li": nil,
+			"dl": nil, "dt": nil, "dd": nil,
+			"a":     {"href", "title", "rel", "target"},
+			"img":   {"src", "alt", "title", "width", "height"},
+			"table": nil, "caption": nil, "thead": nil, "tbody": nil, "tfoot": nil, "tr": nil,
+			"th": {"align", "colspan", "rowspan", "scope"}, "td": {"align", "colspan", "rowspan"},
+		},
+		GlobalAttributes: []string{"class", "dir", "lang"},
+		URLSchemes:       []string{"http", "https", "mailto", "tel"},
+	}
+}
+
+// UGCPolicy is HTMLPolicy for user-generated content: class attributes
+// are dropped and every link is marked rel="nofollow ugc noopener".
+func UGCPolicy() *Policy {
+	p := HTMLPolicy()
+	p.GlobalAttributes = []string{"dir", "lang"}
+	p.LinkRel = "nofollow ugc noopener"
+
+	return p
+}
+
+var (
+	htmlPolicy = HTMLPolicy()
+	ugcPolicy  = UGCPolicy()
+)
+
+// SanitizeHTML removes everything outside HTMLPolicy from *s*.
+// Use it for HTML written by staff, e.g., in a rich-text editor.
+func SanitizeHTML(s string) template.HTML {
+	return htmlPolicy.SanitizeHTML(s)
+}
+
+// SanitizeUGC removes everything outside UGCPolicy from *s*.
+// Use it for HTML submitted by the public.
+func SanitizeUGC(s string) template.HTML {
+	return ugcPolicy.SanitizeHTML(s)
+}
+
+// UnsafeRawHTML marks *s* as trusted HTML without any checking.
+// Anything reaching it from user data is stored XSS; use it only for markup
+// the application itself produced. The long name is deliberate, so every
+// use is easy to find in review.
+func UnsafeRawHTML(s string) template.HTML {
+	return template.HTML(s)
+}
+
+// MarkdownPolicy allows the elements produced by CommonMark with
+// tables and strikethrough, links to http, https and mailto, and images
+// over http and https.
+func MarkdownPolicy() *Policy {
+	return &Policy{
+		Elements: map[string][]string{
+			"p": nil, "br": nil, "hr": nil,
+			"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+			"blockquote": nil, "pre": nil, "code": {"class"},
+			"em": nil, "strong": nil, "del": nil,
+			"ul": nil, "ol": {"start"}, "li": nil,
+			"a":     {"href", "title"},
+			"img":   {"src", "alt", "title"},
+			"table": nil, "thead": nil, "tbody": nil, "tr": nil,
+			"th": {"align"}, "td": {"align"},
+		},
+		URLSchemes: []string{"http", "https", "mailto"},
+		LinkRel:    "nofollow noopener",
+	}
+}
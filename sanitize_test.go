@@ -0,0 +1,134 @@
+package render
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestPolicySanitize(t *testing.T) {
+
+	blank := HTMLPolicy()
+	blank.LinkTarget = "_blank"
+
+	mailOnly := HTMLPolicy()
+	mailOnly.URLSchemes = []string{"mailto"}
+
+	tests := []struct {
+		name   string
+		policy *Policy
+		in     string
+		want   string
+	}{
+		// Allowed markup
+		{"text", HTMLPolicy(), `a < b & "c"`, `a &lt; b &amp; &#34;c&#34;`},
+		{"formatting", HTMLPolicy(), `<p class="x">a <b>b</b></p>`, `<p class="x">a <b>b</b></p>`},
+		{"void element", HTMLPolicy(), `a<br>b`, `a<br />b`},
+		{"strict", StrictPolicy(), `<p>a <b>b</b></p>`, `a b`},
+
+		// Disallowed attributes and elements
+		{"event handler", HTMLPolicy(), `<p onclick="x()" style="color:red">p</p>`, `<p>p</p>`},
+		{"disallowed element keeps text", HTMLPolicy(), `<font color="red">a</font>`, `a`},
+		{"comment", HTMLPolicy(), `<!-- <script>x</script> -->c`, `c`},
+		{"namespaced attribute", HTMLPolicy(), `<a xlink:href="javascript:x" href="/a">a</a>`, `<a href="/a">a</a>`},
+
+		// Elements whose content is dropped
+		{"script", HTMLPolicy(), `<script>alert(1)</script>ok`, `ok`},
+		{"self-closing script", HTMLPolicy(), `<script/>alert(1)</script><b>x</b>`, `<b>x</b>`},
+		{"self-closing style", HTMLPolicy(), `<style/>p{}</style>ok`, `ok`},
+		{"unclosed script", HTMLPolicy(), `ok<script>alert(1)`, `ok`},
+		{"textarea", HTMLPolicy(), `<textarea><script>alert(1)</script></textarea>z`, `z`},
+		{"svg", HTMLPolicy(), `<svg><script>alert(1)</script><a href="javascript:x">t</a></svg>after`, `after`},
+		{"self-closing svg", HTMLPolicy(), `<svg/>after`, `after`},
+		{"math", HTMLPolicy(), `<math><mi xlink:href="javascript:alert(1)">x</mi></math>after`, `after`},
+
+		// Raw text elements
+		{"noscript", HTMLPolicy(), `<noscript><p title="</noscript><img src=x onerror=alert(1)>"></noscript>`, `<img src="x" />&#34;&gt;`},
+		{"xmp", HTMLPolicy(), `<xmp><img src=x onerror=alert(1)></xmp>`, `&lt;img src=x onerror=alert(1)&gt;`},
+
+		// javascript: URLs
+		{"javascript", HTMLPolicy(), `<a href="javascript:alert(1)">a</a>`, `<a>a</a>`},
+		{"mixed-case javascript", HTMLPolicy(), `<a href="JaVaScRiPt:alert(1)">a</a>`, `<a>a</a>`},
+		{"hex entity javascript", HTMLPolicy(), `<a href="jav&#x61;script:alert(1)">a</a>`, `<a>a</a>`},
+		{"decimal entity javascript", HTMLPolicy(), `<a href="&#106;avascript:alert(1)">a</a>`, `<a>a</a>`},
+		{"tab in javascript", HTMLPolicy(), `<a href="java&#x09;script:alert(1)">a</a>`, `<a>a</a>`},
+		{"leading space javascript", HTMLPolicy(), `<a href=" javascript:alert(1)">a</a>`, `<a>a</a>`},
+		{"data image", HTMLPolicy(), `<img src="data:image/png;base64,xx" onerror="alert(1)">`, `<img />`},
+
+		// Relative and protocol-relative URLs
+		{"relative", HTMLPolicy(), `<a href="/orders?id=1">a</a>`, `<a href="/orders?id=1">a</a>`},
+		{"fragment", HTMLPolicy(), `<a href="#top">a</a>`, `<a href="#top">a</a>`},
+		{"protocol-relative", HTMLPolicy(), `<a href="//example.com/x">a</a>`, `<a href="//example.com/x">a</a>`},
+		{"protocol-relative without http", mailOnly, `<a href="//example.com/x">a</a>`, `<a>a</a>`},
+		{"backslash protocol-relative without http", mailOnly, `<a href="/\example.com/x">a</a>`, `<a>a</a>`},
+		{"double backslash without http", mailOnly, `<a href="\\example.com/x">a</a>`, `<a>a</a>`},
+		{"mailto", mailOnly, `<a href="mailto:a@example.com">a</a>`, `<a href="mailto:a@example.com">a</a>`},
+
+		// Unclosed and misnested tags
+		{"unclosed", HTMLPolicy(), `<b>bold <i>both`, `<b>bold <i>both</i></b>`},
+		{"misnested", HTMLPolicy(), `<b><i>a</b>b</i>`, `<b><i>a</i></b>b`},
+		{"stray end tag", HTMLPolicy(), `a</p>b`, `ab`},
+		{"unterminated attribute", HTMLPolicy(), `ok <a href="/x" title="unterminated`, `ok `},
+		{"unterminated tag", HTMLPolicy(), `ok <b`, `ok `},
+
+		// rel and target
+		{"link kept", HTMLPolicy(), `<a href="/x" rel="me" target="_self">a</a>`, `<a href="/x" rel="me" target="_self">a</a>`},
+		{"ugc rel replaced", UGCPolicy(), `<a href="/x" rel="me" target="_self">a</a>`, `<a href="/x" target="_self" rel="nofollow ugc noopener">a</a>`},
+		{"ugc class dropped", UGCPolicy(), `<p class="x">p</p>`, `<p>p</p>`},
+		{"blank target", blank, `<a href="/x" rel="me" target="_self">a</a>`, `<a href="/x" rel="me noopener" target="_blank">a</a>`},
+		{"blank target without rel", blank, `<a href="/x">a</a>`, `<a href="/x" rel="noopener" target="_blank">a</a>`},
+		{"blank target keeps noopener", blank, `<a href="/x" rel="noopener">a</a>`, `<a href="/x" rel="noopener" target="_blank">a</a>`},
+		{"markdown rel", MarkdownPolicy(), `<a href="https://example.com" rel="me">a</a>`, `<a href="https://example.com" rel="nofollow noopener">a</a>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Sanitize(tt.in); got != tt.want {
+				t.Errorf("Sanitize(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowElement(t *testing.T) {
+	p := UGCPolicy().AllowElement("details", "open").AllowElement("summary")
+
+	in := `<details open onclick="x()"><summary>More</summary>text</details>`
+	want := `<details open=""><summary>More</summary>text</details>`
+
+	if got := p.Sanitize(in); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// The template functions that accept markup all go through a policy
+func TestSanitizingFuncs(t *testing.T) {
+
+	const in = `<b>ok</b><script>alert(1)</script><a href="javascript:alert(1)" onclick="x()">a</a>`
+
+	funcs := GetFuncMap()
+
+	for _, name := range []string{"safe", "htmlEscape", "sanitizeHTML", "sanitizeUGC"} {
+		f, ok := funcs[name].(func(string) template.HTML)
+		if !ok {
+			t.Errorf("%s: unexpected signature %T", name, funcs[name])
+			continue
+		}
+
+		got := string(f(in))
+		if strings.Contains(got, "script") || strings.Contains(got, "javascript") || strings.Contains(got, "onclick") {
+			t.Errorf("%s(%q) = %q", name, in, got)
+		}
+		if !strings.Contains(got, "<b>ok</b>") {
+			t.Errorf("%s(%q) = %q, lost allowed markup", name, in, got)
+		}
+	}
+
+	got, err := Markdown("**ok** <script>alert(1)</script> [a](javascript:alert(1))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(got), "<script") || strings.Contains(string(got), "javascript") {
+		t.Errorf("Markdown = %q", got)
+	}
+}
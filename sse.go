@@ -0,0 +1,221 @@
+package render
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultKeepalive is how often an idle EventStream sends a comment, so
+// proxies and load balancers don't close the connection
+const DefaultKeepalive = 15 * time.Second
+
+// ErrStreamClosed is returned when sending on a closed EventStream or
+// after the client disconnected
+var ErrStreamClosed = errors.New("event stream closed")
+
+// Event is one Server-Sent Event
+type Event struct {
+	ID    string        // sent as id:, the Last-Event-ID the browser resumes from
+	Event string        // event type; "" means "message"
+	Data  string        // may contain newlines
+	Retry time.Duration // reconnection delay for the browser, if set
+}
+
+// EventStream is a text/event-stream response that stays open until the
+// client disconnects or Close is called:
+//
+//	stream, err := render.NewEventStream(w, r, 0)
+//	if err != nil {
+//		return err
+//	}
+//	defer stream.Close()
+//
+//	for {
+//		select {
+//		case <-stream.Done():
+//			return nil
+//		case s := <-updates:
+//			if err := stream.Fragment("score", renderer, "score-row", s); err != nil {
+//				return err
+//			}
+//		}
+//	}
+//
+// In the browser, swap the HTML in place:
+//
+//	new EventSource("/scores").addEventListener("score", e => {
+//		document.getElementById("scores").innerHTML = e.data
+//	})
+type EventStream struct {
+	w   http.ResponseWriter
+	r   *http.Request
+	rc  *http.ResponseController
+	ctx context.Context
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	closed bool
+}
+
+// NewEventStream starts an event stream on *w*, sending a comment every
+// *keepalive* while idle; 0 means DefaultKeepalive, a negative value none.
+// It fails if *w* can't be flushed.
+func NewEventStream(w http.ResponseWriter, r *http.Request, keepalive time.Duration) (*EventStream, error) {
+
+	ctx, cancel := context.WithCancel(r.Context())
+
+	s := &EventStream{
+		w:      w,
+		r:      r,
+		rc:     http.NewResponseController(w),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no") // nginx
+
+	w.WriteHeader(http.StatusOK)
+
+	if err := s.rc.Flush(); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if keepalive == 0 {
+		keepalive = DefaultKeepalive
+	}
+
+	if keepalive > 0 {
+		go s.keepalive(keepalive)
+	}
+
+	return s, nil
+}
+
+func (s *EventStream) keepalive(every time.Duration) {
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.write(":\n\n"); err != nil {
+				s.Close()
+				return
+			}
+		}
+	}
+}
+
+// Done is closed when the client disconnects or the stream is closed
+func (s *EventStream) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// Context is the request context, canceled when the stream ends
+func (s *EventStream) Context() context.Context {
+	return s.ctx
+}
+
+// Close stops the stream; the response ends when the handler returns
+func (s *EventStream) Close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	s.cancel()
+}
+
+// Send writes *e* and flushes it to the client
+func (s *EventStream) Send(e Event) error {
+
+	var sb strings.Builder
+
+	if e.ID != "" {
+		sb.WriteString("id: " + singleLine(e.ID) + "\n")
+	}
+
+	if e.Event != "" {
+		sb.WriteString("event: " + singleLine(e.Event) + "\n")
+	}
+
+	if e.Retry > 0 {
+		sb.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+
+	// The stream format ends lines at \r\n, \n or a lone \r, so a \r left
+	// in a data line would split it and inject a field
+	data := newlines.Replace(e.Data)
+	for _, line := range strings.Split(data, "\n") {
+		sb.WriteString("data: " + line + "\n")
+	}
+
+	sb.WriteString("\n")
+
+	return s.write(sb.String())
+}
+
+// JSON sends *v*, marshaled, as an *event* event
+func (s *EventStream) JSON(event string, v interface{}) error {
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.Send(Event{Event: event, Data: string(b)})
+}
+
+// HTML sends a fragment, e.g., from ToHTML or RenderFragment, as an *event* event
+func (s *EventStream) HTML(event string, html template.HTML) error {
+	return s.Send(Event{Event: event, Data: string(html)})
+}
+
+// Fragment renders set *name* of *rd* for the stream's request and sends
+// it as an *event* event. Nothing is sent if rendering fails.
+func (s *EventStream) Fragment(event string, rd *Renderer, name string, model interface{}) error {
+
+	html, err := rd.ToHTML(s.r, name, model)
+	if err != nil {
+		return err
+	}
+
+	return s.HTML(event, html)
+}
+
+func (s *EventStream) write(chunk string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || s.ctx.Err() != nil {
+		return ErrStreamClosed
+	}
+
+	if _, err := s.w.Write([]byte(chunk)); err != nil {
+		return err
+	}
+
+	return s.rc.Flush()
+}
+
+// newlines maps every line ending the stream format accepts to \n
+var newlines = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// singleLine keeps a field from breaking the event framing
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,44 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEventStreamSend(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		event Event
+		want  string
+	}{
+		{"data", Event{Data: "a"}, "data: a\n\n"},
+		{"empty", Event{}, "data: \n\n"},
+		{"fields", Event{ID: "7", Event: "score", Data: "a"}, "id: 7\nevent: score\ndata: a\n\n"},
+		{"lf", Event{Data: "a\nb"}, "data: a\ndata: b\n\n"},
+		{"crlf", Event{Data: "a\r\nb"}, "data: a\ndata: b\n\n"},
+		{"lone cr", Event{Data: "a\revent: x\rb"}, "data: a\ndata: event: x\ndata: b\n\n"},
+		{"cr before lf", Event{Data: "a\r\r\nb"}, "data: a\ndata: \ndata: b\n\n"},
+		{"newline in id", Event{ID: "1\r\ndata: x", Data: "a"}, "id: 1data: x\ndata: a\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s, err := NewEventStream(w, httptest.NewRequest(http.MethodGet, "/", nil), -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			if err := s.Send(tt.event); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Send(%+v) wrote %q, want %q", tt.event, got, tt.want)
+			}
+		})
+	}
+}
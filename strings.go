@@ -0,0 +1,184 @@
+package render
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Ellipsis is appended by Truncate and TruncateWords
+const Ellipsis = "…"
+
+// FirstInitial returns the first character of *name*, as the reader
+// sees it (e.g., "É" even when written as E plus a combining accent).
+// It returns "" for an empty name.
+func FirstInitial(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ""
+	}
+
+	g := uniseg.NewGraphemes(name)
+	g.Next()
+
+	return g.Str()
+}
+
+// Initials returns the upper-cased first character of each part of a
+// full name, e.g., "mary-jane van dyke" -> "MJVD", "Łukasz Żak" -> "ŁŻ".
+func Initials(name string) string {
+
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '-' || r == '.'
+	})
+
+	sb := strings.Builder{}
+	for _, part := range parts {
+		sb.WriteString(FirstInitial(part))
+	}
+
+	return cases.Upper(language.Und).String(sb.String())
+}
+
+// ToUppercase upper-cases *v* using language-neutral rules
+func ToUppercase(v string) string {
+	return cases.Upper(language.Und).String(v)
+}
+
+// ToLowercase lower-cases *v* using language-neutral rules
+func ToLowercase(v string) string {
+	return cases.Lower(language.Und).String(v)
+}
+
+// ToTitleCase capitalizes the first letter of each word in *v*.
+// It replaces the deprecated strings.Title.
+func ToTitleCase(v string) string {
+	return cases.Title(language.Und, cases.NoLower).String(v)
+}
+
+// UppercaseIn upper-cases *v* following the rules of *lang*,
+// e.g., Turkish "i" becomes "İ". Unknown tags fall back to neutral rules.
+func UppercaseIn(lang, v string) string {
+	return cases.Upper(caseTag(lang)).String(v)
+}
+
+// LowercaseIn lower-cases *v* following the rules of *lang*,
+// e.g., Turkish "I" becomes "ı".
+func LowercaseIn(lang, v string) string {
+	return cases.Lower(caseTag(lang)).String(v)
+}
+
+// TitleCaseIn title-cases *v* following the rules of *lang*,
+// e.g., Dutch "ijsselmeer" becomes "IJsselmeer".
+func TitleCaseIn(lang, v string) string {
+	return cases.Title(caseTag(lang), cases.NoLower).String(v)
+}
+
+func caseTag(lang string) language.Tag {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return language.Und
+	}
+
+	return tag
+}
+
+// Truncate shortens *v* to at most *n* user-perceived characters,
+// ending with an ellipsis when anything was cut. It never splits
+// an accented letter, emoji or flag.
+func Truncate(v string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+
+	if uniseg.GraphemeClusterCount(v) <= n {
+		return v
+	}
+
+	return strings.TrimRightFunc(graphemePrefix(v, n-1), unicode.IsSpace) + Ellipsis
+}
+
+// TruncateWords shortens *v* like Truncate, but cuts at the last word
+// boundary that fits, so words aren't broken. A single word longer than
+// *n* falls back to Truncate.
+func TruncateWords(v string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+
+	if uniseg.GraphemeClusterCount(v) <= n {
+		return v
+	}
+
+	// Leave room for the ellipsis
+	prefix := graphemePrefix(v, n-1)
+
+	// A cut right before a space already lands on a word boundary
+	if rest := v[len(prefix):]; !strings.HasSuffix(prefix, " ") && !unicode.IsSpace([]rune(rest)[0]) {
+		i := strings.LastIndexFunc(prefix, unicode.IsSpace)
+		if i <= 0 {
+			return Truncate(v, n)
+		}
+		prefix = prefix[:i]
+	}
+
+	prefix = strings.TrimRightFunc(prefix, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+
+	if prefix == "" {
+		return Truncate(v, n)
+	}
+
+	return prefix + Ellipsis
+}
+
+// graphemePrefix returns the first *n* grapheme clusters of *v*
+func graphemePrefix(v string, n int) string {
+	g := uniseg.NewGraphemes(v)
+
+	end := 0
+	for i := 0; i < n && g.Next(); i++ {
+		_, end = g.Positions()
+	}
+
+	return v[:end]
+}
+
+// Slugify produces a lower-case, hyphen-separated form of *v* for URLs,
+// e.g., "Café Zürich — 2025!" -> "cafe-zurich-2025". Accents are stripped;
+// letters with no unaccented form (e.g., "ø", CJK) are kept as they are.
+func Slugify(v string) string {
+
+	t := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, v)
+	if err != nil {
+		folded = v
+	}
+
+	folded = cases.Lower(language.Und).String(folded)
+
+	sb := strings.Builder{}
+	dash := false
+
+	for _, r := range folded {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			dash = false
+			sb.WriteRune(r)
+		default:
+			dash = true
+		}
+	}
+
+	return sb.String()
+}
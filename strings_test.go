@@ -0,0 +1,113 @@
+package render
+
+import (
+	"testing"
+)
+
+const (
+	decomposedE  = "E\u0301"                    // E and a combining acute accent
+	technologist = "\U0001F469\u200d\U0001F4BB" // woman, ZWJ, laptop
+)
+
+func TestFirstInitialAndInitials(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		first    string
+		initials string
+	}{
+		{"", "", ""},
+		{"  ann lee", "a", "AL"},
+		{decomposedE + "mile Zola", decomposedE, decomposedE + "Z"},
+		{technologist + " Dev", technologist, technologist + "D"},
+		{"🇺🇸 flag", "🇺🇸", "🇺🇸F"},
+		{"mary-jane van dyke", "m", "MJVD"},
+		{"Łukasz Żak", "Ł", "ŁŻ"},
+		{"ßen", "ß", "SS"},
+	}
+
+	for _, tt := range tests {
+		if got := FirstInitial(tt.name); got != tt.first {
+			t.Errorf("FirstInitial(%q) = %q, want %q", tt.name, got, tt.first)
+		}
+		if got := Initials(tt.name); got != tt.initials {
+			t.Errorf("Initials(%q) = %q, want %q", tt.name, got, tt.initials)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+
+	tests := []struct {
+		v     string
+		n     int
+		want  string
+		words string
+	}{
+		{"Hello", 0, "", ""},
+		{"Hello", 5, "Hello", "Hello"},
+		{"Hello world", 5, "Hell…", "Hell…"},
+		{"The quick brown fox", 12, "The quick b…", "The quick…"},
+		{"The quick brown fox", 10, "The quick…", "The quick…"},
+		{"Hello, world", 8, "Hello,…", "Hello…"},
+		{"Supercalifragilistic", 6, "Super…", "Super…"},
+		{decomposedE + decomposedE + decomposedE, 2, decomposedE + "…", decomposedE + "…"},
+		{technologist + technologist + technologist, 2, technologist + "…", technologist + "…"},
+		{technologist + " " + technologist + " " + technologist, 4, technologist + " " + technologist + "…", technologist + " " + technologist + "…"},
+	}
+
+	for _, tt := range tests {
+		if got := Truncate(tt.v, tt.n); got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.v, tt.n, got, tt.want)
+		}
+		if got := TruncateWords(tt.v, tt.n); got != tt.words {
+			t.Errorf("TruncateWords(%q, %d) = %q, want %q", tt.v, tt.n, got, tt.words)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+
+	for v, want := range map[string]string{
+		"Café Zürich — 2025!":       "cafe-zurich-2025",
+		decomposedE + "mile":        "emile",
+		"İstanbul":                  "istanbul",
+		"Straße":                    "straße",
+		"Ørsted øre":                "ørsted-øre",
+		technologist + " devs only": "devs-only",
+		"  --  ":                    "",
+	} {
+		if got := Slugify(v); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestCaseIn(t *testing.T) {
+
+	tests := []struct {
+		name string
+		fn   func(lang, v string) string
+		lang string
+		v    string
+		want string
+	}{
+		{"Turkish upper dotted", UppercaseIn, "tr", "istanbul", "İSTANBUL"},
+		{"Turkish lower dotless", LowercaseIn, "tr", "ISPARTA", "ısparta"},
+		{"Turkish lower dotted", LowercaseIn, "tr", "İzmir", "izmir"},
+		{"Turkish title", TitleCaseIn, "tr", "izmir", "İzmir"},
+		{"English upper", UppercaseIn, "en", "istanbul", "ISTANBUL"},
+		{"neutral lower keeps the dot", LowercaseIn, "und", "İ", "i\u0307"},
+		{"German eszett", UppercaseIn, "de", "straße", "STRASSE"},
+		{"German title", TitleCaseIn, "de", "straße", "Straße"},
+		{"Dutch title", TitleCaseIn, "nl", "ijsselmeer", "IJsselmeer"},
+		{"combining mark", UppercaseIn, "fr", "e\u0301te\u0301", decomposedE + "T" + decomposedE},
+		{"bad tag", UppercaseIn, "not a tag!", "ß", "SS"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.fn(tt.lang, tt.v); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}